@@ -0,0 +1,89 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+// BaggageBridge configures the two-way bridge between span baggage and Kitex persistent metainfo values.
+type BaggageBridge struct {
+	// BaggageKeys are the baggage items the client mirrors into persistent metainfo under the same keys.
+	BaggageKeys []string
+	// MetainfoKeys are the incoming persistent metainfo values the server sets as baggage items on its span.
+	MetainfoKeys []string
+	// MaxValueSize skips values longer than it, 0 means no limit.
+	MaxValueSize int
+	// MaxTotalSize bounds the total size of the keys and values bridged for one rpc, 0 means no limit.
+	MaxTotalSize int
+}
+
+// WithBaggageBridge enables the baggage bridge in the inject and extract middlewares.
+func WithBaggageBridge(bridge BaggageBridge) Option {
+	return func(o *options) {
+		o.baggageBridge = &bridge
+	}
+}
+
+// sizeBudget tracks the size limits of BaggageBridge.
+type sizeBudget struct {
+	bridge *BaggageBridge
+	total  int
+}
+
+func (b *sizeBudget) allow(k, v string) bool {
+	if b.bridge.MaxValueSize > 0 && len(v) > b.bridge.MaxValueSize {
+		return false
+	}
+	if b.bridge.MaxTotalSize > 0 && b.total+len(k)+len(v) > b.bridge.MaxTotalSize {
+		return false
+	}
+	b.total += len(k) + len(v)
+	return true
+}
+
+// baggageToMetainfo mirrors the allowed baggage items of sc into persistent metainfo.
+func (bridge *BaggageBridge) baggageToMetainfo(ctx context.Context, sc opentracing.SpanContext) context.Context {
+	baggage := map[string]string{}
+	sc.ForeachBaggageItem(func(k, v string) bool {
+		baggage[k] = v
+		return true
+	})
+	budget := sizeBudget{bridge: bridge}
+	for _, k := range bridge.BaggageKeys {
+		if v := baggage[k]; v != "" && budget.allow(k, v) {
+			ctx = metainfo.WithPersistentValue(ctx, k, v)
+		}
+	}
+	return ctx
+}
+
+// metainfoToBaggage sets the allowed persistent metainfo values of ctx as baggage items of span.
+func (bridge *BaggageBridge) metainfoToBaggage(ctx context.Context, span opentracing.Span) {
+	budget := sizeBudget{bridge: bridge}
+	for _, k := range bridge.MetainfoKeys {
+		v, ok := metainfo.GetPersistentValue(ctx, k)
+		if !ok {
+			// keys transited by HTTP2 are converted to CGI variables
+			v, ok = metainfo.GetPersistentValue(ctx, metainfo.HTTPHeaderToCGIVariable(k))
+		}
+		if ok && budget.allow(k, v) {
+			span.SetBaggageItem(k, v)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BaggageBridge(t *testing.T) {
+	convey.Convey("Test_BaggageBridge", t, func() {
+		convey.Convey("baggage to metainfo", func() {
+			bridge := &BaggageBridge{BaggageKeys: []string{"tenant", "user", "missing"}, MaxValueSize: 8}
+			span := mocktracer.New().StartSpan("test")
+			span.SetBaggageItem("tenant", "t1")
+			span.SetBaggageItem("user", "a-very-long-user")
+			span.SetBaggageItem("secret", "s")
+			ctx := bridge.baggageToMetainfo(context.Background(), span.Context())
+			assert.Equal(t, map[string]string{"tenant": "t1"}, metainfo.GetAllPersistentValues(ctx))
+		})
+		convey.Convey("metainfo to baggage", func() {
+			bridge := &BaggageBridge{MetainfoKeys: []string{"tenant", "user-id"}}
+			ctx := metainfo.WithPersistentValue(context.Background(), "tenant", "t1")
+			ctx = metainfo.WithPersistentValue(ctx, "USER_ID", "u1")
+			ctx = metainfo.WithPersistentValue(ctx, "secret", "s")
+			span := mocktracer.New().StartSpan("test")
+			bridge.metainfoToBaggage(ctx, span)
+			assert.Equal(t, "t1", span.BaggageItem("tenant"))
+			assert.Equal(t, "u1", span.BaggageItem("user-id"))
+			assert.Equal(t, "", span.BaggageItem("secret"))
+		})
+		convey.Convey("total size limit", func() {
+			bridge := &BaggageBridge{MetainfoKeys: []string{"a", "b"}, MaxTotalSize: 10}
+			ctx := metainfo.WithPersistentValue(context.Background(), "a", strings.Repeat("x", 5))
+			ctx = metainfo.WithPersistentValue(ctx, "b", strings.Repeat("y", 5))
+			span := mocktracer.New().StartSpan("test")
+			bridge.metainfoToBaggage(ctx, span)
+			assert.Equal(t, "xxxxx", span.BaggageItem("a"))
+			assert.Equal(t, "", span.BaggageItem("b"))
+		})
+	})
+}
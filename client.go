@@ -16,13 +16,14 @@ package opentracing
 
 import (
 	"context"
+	"sync"
 
 	"github.com/cloudwego/kitex/client"
 	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/cloudwego/kitex/pkg/stats"
 	"github.com/cloudwego/kitex/pkg/transmeta"
-	"github.com/cloudwego/kitex/transport"
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 )
 
 var _ stats.Tracer = &clientTracer{}
@@ -31,60 +32,127 @@ type clientTracer struct {
 	commonTracer
 }
 
+// clientContainer records the span started for the rpc, span is nil when the rpc is not traced.
+type clientContainer struct {
+	clientTracer *clientTracer
+	span         opentracing.Span
+	attempts     attempts
+	connMu       sync.Mutex
+	conn         *connInfo
+}
+
+// setConn records the connection acquired by the latest attempt of the rpc.
+func (cc *clientContainer) setConn(ci *connInfo) {
+	cc.connMu.Lock()
+	cc.conn = ci
+	cc.connMu.Unlock()
+}
+
+// clientSpanFromContext returns the span started by clientTracer for the rpc in ctx.
+func clientSpanFromContext(ctx context.Context) opentracing.Span {
+	cc, ok := ctx.Value(clientContainerKey).(*clientContainer)
+	if !ok {
+		return nil
+	}
+	return cc.span
+}
+
 func (o *clientTracer) Start(ctx context.Context) context.Context {
-	var operationName string
-	if o.formOperationName != nil {
-		operationName = o.formOperationName(ctx)
+	opts, ok := o.startOptions(ctx)
+	if !ok {
+		// shadow the container of any outer rpc so that Finish leaves it alone
+		return context.WithValue(ctx, clientContainerKey, &clientContainer{clientTracer: o})
 	}
 	ri := rpcinfo.GetRPCInfo(ctx)
-	startTime := ri.Stats().GetEvent(stats.RPCStart).Time()
-	_, ctx = opentracing.StartSpanFromContextWithTracer(ctx, o.tracer, operationName, opentracing.StartTime(startTime))
-	return ctx
+	opts = append(opts, ext.SpanKindRPCClient)
+	if event := ri.Stats().GetEvent(stats.RPCStart); event != nil {
+		opts = append(opts, opentracing.StartTime(event.Time()))
+	}
+	if opentracing.SpanFromContext(ctx) == nil {
+		if pt := passThroughFromContext(ctx); pt != nil {
+			opts = append(opts, opentracing.ChildOf(pt.spanContext))
+		}
+	}
+	rpcSpan, ctx := opentracing.StartSpanFromContextWithTracer(ctx, o.tracer, o.operationName(ctx), opts...)
+	setRPCTags(rpcSpan, ri)
+	ext.PeerService.Set(rpcSpan, ri.To().ServiceName())
+	setTimeoutTags(ctx, rpcSpan, ri.Config())
+	o.decorateStart(ctx, rpcSpan, ri)
+	return context.WithValue(ctx, clientContainerKey, &clientContainer{clientTracer: o, span: rpcSpan})
 }
 
 func (o *clientTracer) Finish(ctx context.Context) {
-	rpcSpan := opentracing.SpanFromContext(ctx)
-
+	cc, ok := ctx.Value(clientContainerKey).(*clientContainer)
+	if !ok || cc.span == nil {
+		return
+	}
+	rpcSpan := cc.span
 	ri := rpcinfo.GetRPCInfo(ctx)
 	st := ri.Stats()
 
-	// new common rpc span
-	o.newCommonSpan(rpcSpan, st)
-	// new establish connection span
-	o.newEventSpan("establish connection", st, stats.ClientConnStart, stats.ClientConnFinish, rpcSpan.Context())
+	// the remote address is only known after the load balancer picked an instance,
+	// ri holds the one of the first attempt, the winning attempt may have called another instance
+	peer := ri.To()
+	if winner := cc.finishAttempts(); winner != nil {
+		peer = winner
+	}
+	setPeerTags(rpcSpan, peer)
+	setInstanceTags(rpcSpan, peer, o.instanceTags)
+	cc.connMu.Lock()
+	if cc.conn != nil {
+		cc.conn.setTags(rpcSpan)
+	}
+	cc.connMu.Unlock()
+	recordRPCError(rpcSpan, st)
+	recordContextDone(ctx, rpcSpan, st.Error())
+
+	finishOptions := opentracing.FinishOptions{FinishTime: rpcFinishTime(st)}
+	// record common rpc events
+	o.recordCommonEvents(rpcSpan, st, &finishOptions)
+	// record establish connection event
+	o.recordEvent(rpcSpan, &finishOptions, st, EventGroupConnection, "establish connection", stats.ClientConnStart, stats.ClientConnFinish)
 
-	rpcSpan.FinishWithOptions(opentracing.FinishOptions{FinishTime: st.GetEvent(stats.RPCFinish).Time()})
+	o.decorateFinish(ctx, rpcSpan, ri)
+	rpcSpan.FinishWithOptions(finishOptions)
 }
 
-// clientOption return client option with specified tracer and operation name formater.
-func clientOption(tracer opentracing.Tracer, formOperationName func(c context.Context) string) client.Option {
+// clientOption return client option with the specified options.
+func clientOption(opts *options) client.Option {
 	ct := &clientTracer{}
-	ct.tracer = tracer
-	ct.formOperationName = formOperationName
+	ct.options = opts
 	return client.WithTracer(ct)
 }
 
+// NewDefaultClientSuite uses opentracing global tracer and `{Service Name}::{Method Name}` as operation name.
 func NewDefaultClientSuite() client.Suite {
-	return &clientSuite{opentracing.GlobalTracer(), func(ctx context.Context) string {
-		endpoint := rpcinfo.GetRPCInfo(ctx).From()
-		return endpoint.ServiceName() + "::" + endpoint.Method()
-	}}
+	return NewClientSuiteWithOptions()
 }
 
+// NewClientSuite returns a client suite with specified tracer and operation name formatter.
 func NewClientSuite(tracer opentracing.Tracer, formOperationName func(c context.Context) string) client.Suite {
-	return &clientSuite{tracer, formOperationName}
+	return NewClientSuiteWithOptions(WithTracer(tracer), WithOperationNameFunc(formOperationName))
+}
+
+// NewClientSuiteWithOptions returns a client suite configured by opts.
+// The transport protocol of the client is kept unless WithTransportProtocol is given,
+// span context is transited in TTHeader or in gRPC metadata over HTTP2.
+func NewClientSuiteWithOptions(opts ...Option) client.Suite {
+	return &clientSuite{newOptions(opts)}
 }
 
 type clientSuite struct {
-	tracer            opentracing.Tracer
-	formOperationName func(c context.Context) string
+	opts *options
 }
 
 func (c *clientSuite) Options() []client.Option {
 	var options []client.Option
-	options = append(options, clientOption(c.tracer, c.formOperationName))
+	options = append(options, clientOption(c.opts))
 	options = append(options, client.WithMiddleware(SpanContextInjectMW))
-	options = append(options, client.WithTransportProtocol(transport.TTHeader))
+	if c.opts.transportProtocol != nil {
+		options = append(options, client.WithTransportProtocol(*c.opts.transportProtocol))
+	}
+	// the meta handlers only work with their own protocol
 	options = append(options, client.WithMetaHandler(transmeta.ClientTTHeaderHandler))
+	options = append(options, client.WithMetaHandler(transmeta.ClientHTTP2Handler))
 	return options
 }
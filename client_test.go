@@ -0,0 +1,122 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/transport"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClientTracer(opts ...Option) *clientTracer {
+	ct := &clientTracer{}
+	ct.options = newOptions(opts)
+	return ct
+}
+
+func Test_clientTracer(t *testing.T) {
+	convey.Convey("Test_clientTracer", t, func() {
+		convey.Convey("success", func() {
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer))
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+
+			spans := tracer.FinishedSpans()
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "client::Call", spans[0].OperationName)
+			assert.Equal(t, ext.SpanKindRPCClientEnum, spans[0].Tag("span.kind"))
+			assert.Equal(t, "echo", spans[0].Tag("peer.service"))
+			assert.Equal(t, "Echo", spans[0].Tag(tagRPCMethod))
+			assert.Equal(t, "127.0.0.1", spans[0].Tag("peer.ipv4"))
+		})
+		convey.Convey("filtered", func() {
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer), WithFilter(func(ctx context.Context) bool {
+				return false
+			}))
+			parent := tracer.StartSpan("parent")
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = opentracing.ContextWithSpan(ctx, parent)
+			ctx = ct.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			assert.Len(t, tracer.FinishedSpans(), 0)
+		})
+		convey.Convey("decorator", func() {
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer), WithSpanDecorator(func(ctx context.Context, span opentracing.Span, ri rpcinfo.RPCInfo) {
+				span.SetTag("tenant", "t1")
+			}))
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			assert.Equal(t, "t1", tracer.FinishedSpans()[0].Tag("tenant"))
+		})
+		convey.Convey("start and finish decorators", func() {
+			tracer := mocktracer.New()
+			var started bool
+			ct := newTestClientTracer(WithTracer(tracer), WithOnStart(func(ctx context.Context, span opentracing.Span, ri rpcinfo.RPCInfo) {
+				started = opentracing.SpanFromContext(ctx) == span
+				span.SetTag("region", "cn")
+			}), WithOnFinish(func(ctx context.Context, span opentracing.Span, ri rpcinfo.RPCInfo) {
+				span.SetTag("finished", ri.Stats().GetEvent(stats.RPCFinish) != nil)
+			}))
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			assert.True(t, started)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			span := tracer.FinishedSpans()[0]
+			assert.Equal(t, "cn", span.Tag("region"))
+			assert.Equal(t, true, span.Tag("finished"))
+		})
+	})
+}
+
+func Test_clientSuite(t *testing.T) {
+	convey.Convey("Test_clientSuite", t, func() {
+		apply := func(suite client.Suite) *client.Options {
+			o := &client.Options{Configs: rpcinfo.NewRPCConfig()}
+			for _, opt := range suite.Options() {
+				opt.F(o, &o.DebugInfo)
+			}
+			return o
+		}
+		convey.Convey("default", func() {
+			o := apply(NewDefaultClientSuite())
+			assert.Contains(t, o.MetaHandlers, transmeta.ClientTTHeaderHandler)
+			assert.Contains(t, o.MetaHandlers, transmeta.ClientHTTP2Handler)
+			assert.Equal(t, transport.PurePayload, o.Configs.TransportProtocol())
+		})
+		convey.Convey("transport protocol", func() {
+			o := apply(NewClientSuiteWithOptions(WithTransportProtocol(transport.TTHeader)))
+			assert.Equal(t, transport.TTHeader, o.Configs.TransportProtocol())
+		})
+	})
+}
@@ -0,0 +1,262 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Sampler types of SamplerConfig.
+const (
+	SamplerTypeAlways        = "always"
+	SamplerTypeNever         = "never"
+	SamplerTypeProbabilistic = "probabilistic"
+	SamplerTypeRateLimiting  = "rate_limiting"
+)
+
+// Config is the tracing configuration read by the suites on each rpc, it can be swapped at runtime through a ConfigStore.
+type Config struct {
+	// Disabled stops tracing all rpcs.
+	Disabled bool `json:"disabled" yaml:"disabled"`
+	// SkipMethods are the methods not traced.
+	SkipMethods []string `json:"skip_methods" yaml:"skip_methods"`
+	// Sampling replaces the policy set by WithSamplingPolicy when not nil.
+	Sampling *SamplingConfig `json:"sampling" yaml:"sampling"`
+	// Tags are set on every rpc span.
+	Tags map[string]string `json:"tags" yaml:"tags"`
+}
+
+// SamplingConfig is the serializable form of SamplingPolicy.
+type SamplingConfig struct {
+	Rules         []SamplingRuleConfig `json:"rules" yaml:"rules"`
+	Default       *SamplerConfig       `json:"default" yaml:"default"`
+	SkipUnsampled bool                 `json:"skip_unsampled" yaml:"skip_unsampled"`
+}
+
+// SamplingRuleConfig is the serializable form of SamplingRule.
+type SamplingRuleConfig struct {
+	Service       string `json:"service" yaml:"service"`
+	Method        string `json:"method" yaml:"method"`
+	Caller        string `json:"caller" yaml:"caller"`
+	SamplerConfig `yaml:",inline"`
+}
+
+// SamplerConfig describes a Sampler by its type, Param is the rate of the probabilistic sampler
+// and the spans per second of the rate limiting sampler.
+type SamplerConfig struct {
+	Type  string  `json:"type" yaml:"type"`
+	Param float64 `json:"param" yaml:"param"`
+}
+
+func (c *SamplerConfig) sampler() (Sampler, error) {
+	switch c.Type {
+	case SamplerTypeAlways:
+		return AlwaysSample(), nil
+	case SamplerTypeNever:
+		return NeverSample(), nil
+	case SamplerTypeProbabilistic:
+		return ProbabilisticSampler(c.Param), nil
+	case SamplerTypeRateLimiting:
+		return RateLimitingSampler(c.Param), nil
+	default:
+		return nil, fmt.Errorf("unknown sampler type %q", c.Type)
+	}
+}
+
+func (c *SamplingConfig) policy() (*SamplingPolicy, error) {
+	policy := &SamplingPolicy{SkipUnsampled: c.SkipUnsampled}
+	for _, rc := range c.Rules {
+		sampler, err := rc.sampler()
+		if err != nil {
+			return nil, err
+		}
+		policy.Rules = append(policy.Rules, SamplingRule{Service: rc.Service, Method: rc.Method, Caller: rc.Caller, Sampler: sampler})
+	}
+	if c.Default != nil {
+		sampler, err := c.Default.sampler()
+		if err != nil {
+			return nil, err
+		}
+		policy.Default = sampler
+	}
+	return policy, nil
+}
+
+// loadedConfig holds a Config with the structures built from it, so that the samplers keep their state between rpcs.
+type loadedConfig struct {
+	config         *Config
+	skipMethods    map[string]bool
+	samplingPolicy *SamplingPolicy
+	tags           opentracing.Tags
+}
+
+func newLoadedConfig(cfg *Config) (*loadedConfig, error) {
+	if cfg == nil {
+		return nil, errors.New("tracing config is nil")
+	}
+	lc := &loadedConfig{config: cfg, skipMethods: map[string]bool{}}
+	for _, m := range cfg.SkipMethods {
+		lc.skipMethods[m] = true
+	}
+	if cfg.Sampling != nil {
+		policy, err := cfg.Sampling.policy()
+		if err != nil {
+			return nil, err
+		}
+		lc.samplingPolicy = policy
+	}
+	if len(cfg.Tags) > 0 {
+		lc.tags = opentracing.Tags{}
+		for k, v := range cfg.Tags {
+			lc.tags[k] = v
+		}
+	}
+	return lc, nil
+}
+
+// skip reports whether the rpc in ctx must not be traced.
+func (lc *loadedConfig) skip(ctx context.Context) bool {
+	if lc.config.Disabled {
+		return true
+	}
+	if len(lc.skipMethods) == 0 {
+		return false
+	}
+	ri := rpcinfo.GetRPCInfo(ctx)
+	return ri != nil && ri.Invocation() != nil && lc.skipMethods[ri.Invocation().MethodName()]
+}
+
+// ConfigStore holds the Config read by the suites, it's safe to swap the Config while rpcs are traced.
+// The zero value holds no Config, rpcs are traced as if no ConfigStore was given until Store is called.
+type ConfigStore struct {
+	v atomic.Value
+}
+
+// NewConfigStore returns a ConfigStore holding cfg.
+func NewConfigStore(cfg *Config) (*ConfigStore, error) {
+	s := &ConfigStore{}
+	if err := s.Store(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load returns the current Config, which must not be modified, nil if no Config is stored.
+func (s *ConfigStore) Load() *Config {
+	if lc := s.load(); lc != nil {
+		return lc.config
+	}
+	return nil
+}
+
+// Store swaps the current Config with cfg, the rpcs started afterwards are traced with it.
+// The current Config is kept if cfg is nil or invalid.
+func (s *ConfigStore) Store(cfg *Config) error {
+	lc, err := newLoadedConfig(cfg)
+	if err != nil {
+		return err
+	}
+	s.v.Store(lc)
+	return nil
+}
+
+// load returns the current loadedConfig, nil if the store is nil or empty.
+func (s *ConfigStore) load() *loadedConfig {
+	if s == nil {
+		return nil
+	}
+	lc, _ := s.v.Load().(*loadedConfig)
+	return lc
+}
+
+// ConfigDecoder decodes a configuration file into v, json.Unmarshal and yaml.Unmarshal can be used.
+type ConfigDecoder func(data []byte, v interface{}) error
+
+// LoadConfigFile reads the Config in the file at path with decode, json.Unmarshal is used if decode is nil.
+func LoadConfigFile(path string, decode ConfigDecoder) (*Config, error) {
+	if decode == nil {
+		decode = json.Unmarshal
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err = decode(data, cfg); err != nil {
+		return nil, fmt.Errorf("decode tracing config %s failed, %w", path, err)
+	}
+	return cfg, nil
+}
+
+// WatchFile loads the Config in the file at path into s, then polls the file every interval and reloads it
+// when it's modified until ctx is done. Errors after the first load are passed to onError if not nil,
+// and the last valid Config is kept.
+func (s *ConfigStore) WatchFile(ctx context.Context, path string, decode ConfigDecoder, interval time.Duration, onError func(error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err = s.loadFile(path, decode); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			latest, err := os.Stat(path)
+			if err == nil && latest.ModTime().Equal(info.ModTime()) && latest.Size() == info.Size() {
+				continue
+			}
+			if err == nil {
+				info = latest
+				err = s.loadFile(path, decode)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *ConfigStore) loadFile(path string, decode ConfigDecoder) error {
+	cfg, err := LoadConfigFile(path, decode)
+	if err != nil {
+		return err
+	}
+	return s.Store(cfg)
+}
+
+// WithConfigStore makes the suites read the Config in store on each rpc.
+func WithConfigStore(store *ConfigStore) Option {
+	return func(o *options) {
+		o.configStore = store
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConfigStore(t *testing.T) {
+	convey.Convey("Test_ConfigStore", t, func() {
+		convey.Convey("swap config", func() {
+			store, err := NewConfigStore(&Config{Tags: map[string]string{"env": "prod"}})
+			assert.Nil(t, err)
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer), WithConfigStore(store))
+
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			assert.Len(t, tracer.FinishedSpans(), 1)
+			assert.Equal(t, "prod", tracer.FinishedSpans()[0].Tag("env"))
+
+			assert.Nil(t, store.Store(&Config{SkipMethods: []string{"Echo"}}))
+			ctx, ri = newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			assert.Len(t, tracer.FinishedSpans(), 1)
+		})
+		convey.Convey("zero value", func() {
+			store := &ConfigStore{}
+			assert.Nil(t, store.Load())
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer), WithConfigStore(store))
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			assert.Len(t, tracer.FinishedSpans(), 1)
+		})
+		convey.Convey("nil config", func() {
+			_, err := NewConfigStore(nil)
+			assert.NotNil(t, err)
+			store, _ := NewConfigStore(&Config{Tags: map[string]string{"env": "prod"}})
+			assert.NotNil(t, store.Store(nil))
+			assert.Equal(t, "prod", store.Load().Tags["env"])
+		})
+		convey.Convey("invalid sampler", func() {
+			store, _ := NewConfigStore(&Config{})
+			err := store.Store(&Config{Sampling: &SamplingConfig{Default: &SamplerConfig{Type: "adaptive"}}})
+			assert.NotNil(t, err)
+			assert.NotNil(t, store.Load())
+			assert.Nil(t, store.Load().Sampling)
+		})
+		convey.Convey("watch file", func() {
+			dir, _ := ioutil.TempDir("", "tracing")
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "tracing.json")
+			_ = ioutil.WriteFile(path, []byte(`{"disabled": true}`), 0o644)
+
+			store, _ := NewConfigStore(&Config{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			assert.Nil(t, store.WatchFile(ctx, path, nil, 10*time.Millisecond, nil))
+			assert.True(t, store.Load().Disabled)
+
+			_ = ioutil.WriteFile(path, []byte(`{"sampling": {"default": {"type": "probabilistic", "param": 0.5}}}`), 0o644)
+			for i := 0; i < 100 && store.Load().Disabled; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			assert.False(t, store.Load().Disabled)
+			assert.Equal(t, 0.5, store.Load().Sampling.Default.Param)
+		})
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	tagConnReused        = "kitex.conn.reused"
+	tagConnDialMS        = "kitex.conn.dial_ms"
+	tagConnLocalAddress  = "kitex.conn.local_address"
+	tagConnRemoteAddress = "kitex.conn.remote_address"
+	tagConnPoolActive    = "kitex.conn.pool.active"
+)
+
+// connInfo describes the connection acquired by an rpc.
+type connInfo struct {
+	reused     bool
+	dial       time.Duration
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	active     int64
+}
+
+func (ci *connInfo) setTags(span opentracing.Span) {
+	span.SetTag(tagConnReused, ci.reused)
+	if !ci.reused {
+		span.SetTag(tagConnDialMS, float64(ci.dial)/float64(time.Millisecond))
+	}
+	if ci.localAddr != nil {
+		span.SetTag(tagConnLocalAddress, ci.localAddr.String())
+	}
+	if ci.remoteAddr != nil {
+		span.SetTag(tagConnRemoteAddress, ci.remoteAddr.String())
+	}
+	span.SetTag(tagConnPoolActive, ci.active)
+}
+
+// WrapConnPool wraps pool so that client spans are tagged with whether the connection was reused or dialed,
+// the dial duration, the socket addresses and the count of connections to the address in use.
+// Kitex creates the default pool after the client options are applied, so pass the pool explicitly:
+//
+//	client.WithConnPool(WrapConnPool(connpool.NewLongPool("echo", idleConfig)))
+func WrapConnPool(pool remote.ConnPool) remote.ConnPool {
+	tp := &tracedConnPool{pool: pool}
+	if lp, ok := pool.(remote.LongConnPool); ok {
+		return &tracedLongConnPool{tracedConnPool: tp, longPool: lp}
+	}
+	return tp
+}
+
+type tracedConnPool struct {
+	pool remote.ConnPool
+	// active counts the connections in use per address
+	active sync.Map
+	// addresses maps the connections in use to their address
+	addresses sync.Map
+}
+
+var _ remote.ConnPool = &tracedConnPool{}
+
+// dialRecorder records whether the pool dialed a new connection and how long it took.
+type dialRecorder struct {
+	remote.Dialer
+	dialed   bool
+	duration time.Duration
+}
+
+func (d *dialRecorder) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	start := time.Now()
+	conn, err := d.Dialer.DialTimeout(network, address, timeout)
+	d.dialed, d.duration = true, time.Since(start)
+	return conn, err
+}
+
+// Get implements the remote.ConnPool interface.
+func (p *tracedConnPool) Get(ctx context.Context, network, address string, opt *remote.ConnOption) (net.Conn, error) {
+	cc, _ := ctx.Value(clientContainerKey).(*clientContainer)
+	if cc == nil || cc.span == nil || opt == nil || opt.Dialer == nil {
+		return p.pool.Get(ctx, network, address, opt)
+	}
+	dialer := &dialRecorder{Dialer: opt.Dialer}
+	conn, err := p.pool.Get(ctx, network, address, &remote.ConnOption{Dialer: dialer, ConnectTimeout: opt.ConnectTimeout})
+	if err != nil {
+		return conn, err
+	}
+	cc.setConn(&connInfo{
+		reused:     !dialer.dialed,
+		dial:       dialer.duration,
+		localAddr:  conn.LocalAddr(),
+		remoteAddr: conn.RemoteAddr(),
+		active:     p.acquire(conn, address),
+	})
+	return conn, nil
+}
+
+// acquire counts conn as in use and returns the count of connections to address in use.
+func (p *tracedConnPool) acquire(conn net.Conn, address string) int64 {
+	counter, _ := p.active.LoadOrStore(address, new(int64))
+	p.addresses.Store(conn, address)
+	return atomic.AddInt64(counter.(*int64), 1)
+}
+
+func (p *tracedConnPool) release(conn net.Conn) {
+	address, ok := p.addresses.LoadAndDelete(conn)
+	if !ok {
+		return
+	}
+	if counter, ok := p.active.Load(address); ok {
+		atomic.AddInt64(counter.(*int64), -1)
+	}
+}
+
+// Put implements the remote.ConnPool interface.
+func (p *tracedConnPool) Put(conn net.Conn) error {
+	p.release(conn)
+	return p.pool.Put(conn)
+}
+
+// Discard implements the remote.ConnPool interface.
+func (p *tracedConnPool) Discard(conn net.Conn) error {
+	p.release(conn)
+	return p.pool.Discard(conn)
+}
+
+// Close implements the remote.ConnPool interface.
+func (p *tracedConnPool) Close() error {
+	return p.pool.Close()
+}
+
+// EnableReporter implements the remote.ConnPoolReporter interface.
+func (p *tracedConnPool) EnableReporter() {
+	if r, ok := p.pool.(remote.ConnPoolReporter); ok {
+		r.EnableReporter()
+	}
+}
+
+// Dump dumps the wrapped pool for debug queries.
+func (p *tracedConnPool) Dump() interface{} {
+	if d, ok := p.pool.(interface{ Dump() interface{} }); ok {
+		return d.Dump()
+	}
+	return nil
+}
+
+type tracedLongConnPool struct {
+	*tracedConnPool
+	longPool remote.LongConnPool
+}
+
+var _ remote.LongConnPool = &tracedLongConnPool{}
+
+// Clean implements the remote.LongConnPool interface.
+func (p *tracedLongConnPool) Clean(network, address string) {
+	p.longPool.Clean(network, address)
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockConn struct {
+	net.Conn
+	local, remote net.Addr
+}
+
+func (c *mockConn) LocalAddr() net.Addr  { return c.local }
+func (c *mockConn) RemoteAddr() net.Addr { return c.remote }
+
+type mockDialer struct{}
+
+func (d *mockDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	local, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:50000")
+	remote, _ := net.ResolveTCPAddr(network, address)
+	return &mockConn{local: local, remote: remote}, nil
+}
+
+// mockPool keeps a single idle connection per address.
+type mockPool struct {
+	idle map[string]net.Conn
+}
+
+func (p *mockPool) Get(ctx context.Context, network, address string, opt *remote.ConnOption) (net.Conn, error) {
+	if conn, ok := p.idle[address]; ok {
+		delete(p.idle, address)
+		return conn, nil
+	}
+	return opt.Dialer.DialTimeout(network, address, opt.ConnectTimeout)
+}
+
+func (p *mockPool) Put(conn net.Conn) error {
+	p.idle[conn.RemoteAddr().String()] = conn
+	return nil
+}
+
+func (p *mockPool) Discard(conn net.Conn) error { return nil }
+func (p *mockPool) Close() error                { return nil }
+
+func Test_WrapConnPool(t *testing.T) {
+	convey.Convey("Test_WrapConnPool", t, func() {
+		tracer := mocktracer.New()
+		ct := newTestClientTracer(WithTracer(tracer))
+		pool := WrapConnPool(&mockPool{idle: map[string]net.Conn{}})
+		opt := &remote.ConnOption{Dialer: &mockDialer{}, ConnectTimeout: time.Second}
+		call := func(release func(net.Conn) error) *mocktracer.MockSpan {
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			conn, err := pool.Get(ctx, "tcp", "10.0.0.1:8888", opt)
+			assert.Nil(t, err)
+			assert.Nil(t, release(conn))
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			spans := tracer.FinishedSpans()
+			return spans[len(spans)-1]
+		}
+
+		span := call(pool.Put)
+		assert.Equal(t, false, span.Tag(tagConnReused))
+		assert.NotNil(t, span.Tag(tagConnDialMS))
+		assert.Equal(t, "127.0.0.1:50000", span.Tag(tagConnLocalAddress))
+		assert.Equal(t, "10.0.0.1:8888", span.Tag(tagConnRemoteAddress))
+		assert.Equal(t, int64(1), span.Tag(tagConnPoolActive))
+
+		span = call(pool.Discard)
+		assert.Equal(t, true, span.Tag(tagConnReused))
+		assert.Nil(t, span.Tag(tagConnDialMS))
+		assert.Equal(t, int64(1), span.Tag(tagConnPoolActive))
+
+		convey.Convey("untraced rpc", func() {
+			conn, err := pool.Get(context.Background(), "tcp", "10.0.0.1:8888", opt)
+			assert.Nil(t, err)
+			assert.NotNil(t, conn)
+		})
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	tagRPCTimeout        = "kitex.timeout.rpc_ms"
+	tagConnectTimeout    = "kitex.timeout.connect_ms"
+	tagReadWriteTimeout  = "kitex.timeout.read_write_ms"
+	tagDeadlineRemaining = "kitex.deadline.remaining_ms"
+	tagContextDone       = "kitex.context.done"
+
+	contextDoneDeadlineExceeded = "deadline_exceeded"
+	contextDoneCanceled         = "canceled"
+)
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// setTimeoutTags sets the timeouts configured for the rpc and the deadline left in ctx on span.
+func setTimeoutTags(ctx context.Context, span opentracing.Span, cfg rpcinfo.RPCConfig) {
+	if cfg != nil {
+		if t := cfg.RPCTimeout(); t > 0 {
+			span.SetTag(tagRPCTimeout, milliseconds(t))
+		}
+		if t := cfg.ConnectTimeout(); t > 0 {
+			span.SetTag(tagConnectTimeout, milliseconds(t))
+		}
+		if t := cfg.ReadWriteTimeout(); t > 0 {
+			span.SetTag(tagReadWriteTimeout, milliseconds(t))
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		span.SetTag(tagDeadlineRemaining, milliseconds(time.Until(deadline)))
+	}
+}
+
+// recordContextDone tags span when the rpc failed with err because its deadline was exceeded or it was canceled.
+func recordContextDone(ctx context.Context, span opentracing.Span, err error) {
+	if err == nil {
+		return
+	}
+	if reason := contextDoneReason(err); reason != "" {
+		span.SetTag(tagContextDone, reason)
+		return
+	}
+	// the error may wrap the cause of the done context only loosely, e.g. a network error on a closed connection
+	if reason := contextDoneReason(ctx.Err()); reason != "" {
+		span.SetTag(tagContextDone, reason)
+	}
+}
+
+func contextDoneReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return contextDoneCanceled
+	case errors.Is(err, context.DeadlineExceeded), kerrors.IsTimeoutError(err):
+		return contextDoneDeadlineExceeded
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_setTimeoutTags(t *testing.T) {
+	convey.Convey("Test_setTimeoutTags", t, func() {
+		convey.Convey("configured timeouts", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			cfg := rpcinfo.NewRPCConfig()
+			_ = rpcinfo.AsMutableRPCConfig(cfg).SetRPCTimeout(200 * time.Millisecond)
+			setTimeoutTags(context.Background(), span, cfg)
+			assert.Equal(t, float64(200), span.Tag(tagRPCTimeout))
+			assert.Equal(t, float64(50), span.Tag(tagConnectTimeout))
+			assert.Equal(t, float64(5000), span.Tag(tagReadWriteTimeout))
+			assert.Nil(t, span.Tag(tagDeadlineRemaining))
+		})
+		convey.Convey("context deadline", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			setTimeoutTags(ctx, span, nil)
+			remaining := span.Tag(tagDeadlineRemaining).(float64)
+			assert.True(t, remaining > 0 && remaining <= float64(time.Minute/time.Millisecond))
+		})
+	})
+}
+
+func Test_recordContextDone(t *testing.T) {
+	convey.Convey("Test_recordContextDone", t, func() {
+		done := func(ctx context.Context, err error) interface{} {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			recordContextDone(ctx, span, err)
+			return span.Tag(tagContextDone)
+		}
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Nil(t, done(canceled, nil))
+		assert.Nil(t, done(context.Background(), errors.New("EOF")))
+		assert.Equal(t, contextDoneDeadlineExceeded, done(context.Background(), kerrors.ErrRPCTimeout.WithCause(errors.New("timeout"))))
+		assert.Equal(t, contextDoneDeadlineExceeded, done(context.Background(), context.DeadlineExceeded))
+		assert.Equal(t, contextDoneCanceled, done(context.Background(), kerrors.ErrRemoteOrNetwork.WithCause(context.Canceled)))
+		assert.Equal(t, contextDoneCanceled, done(canceled, errors.New("EOF")))
+	})
+}
+
+func Test_clientTracerTimeoutTags(t *testing.T) {
+	convey.Convey("Test_clientTracerTimeoutTags", t, func() {
+		tracer := mocktracer.New()
+		ct := newTestClientTracer(WithTracer(tracer))
+		ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+		ctx = ct.Start(ctx)
+		ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusError, "")
+		rpcinfo.AsMutableRPCStats(ri.Stats()).SetError(kerrors.ErrRPCTimeout)
+		ct.Finish(ctx)
+
+		span := tracer.FinishedSpans()[0]
+		assert.Equal(t, float64(1000), span.Tag(tagRPCTimeout))
+		assert.Equal(t, contextDoneDeadlineExceeded, span.Tag(tagContextDone))
+	})
+}
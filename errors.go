@@ -0,0 +1,150 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	tracerLog "github.com/opentracing/opentracing-go/log"
+)
+
+const (
+	tagErrorType        = "kitex.error.type"
+	tagBizStatusCode    = "kitex.biz_status.code"
+	tagBizStatusMessage = "kitex.biz_status.message"
+
+	logEvent     = "event"
+	logErrorKind = "error.kind"
+	logMessage   = "message"
+	logStack     = "stack"
+)
+
+// Error types recorded in the kitex.error.type tag and the error.kind log field.
+const (
+	ErrorTypePanic        = "panic"
+	ErrorTypeTimeout      = "timeout"
+	ErrorTypeTransport    = "transport"
+	ErrorTypeBizError     = "biz_error"
+	ErrorTypeBizException = "biz_exception"
+	ErrorTypeBizStatus    = "biz_status"
+	ErrorTypeInternal     = "internal"
+)
+
+// bizStatusError is implemented by errors carrying a business status code and message.
+type bizStatusError interface {
+	error
+	BizStatusCode() int32
+	BizMessage() string
+}
+
+type stackError interface {
+	Stack() string
+}
+
+// errorType classifies err into one of the ErrorType values.
+func errorType(err error) string {
+	var bizErr bizStatusError
+	switch {
+	case errors.As(err, &bizErr):
+		return ErrorTypeBizStatus
+	case errors.Is(err, kerrors.ErrPanic):
+		return ErrorTypePanic
+	case kerrors.IsTimeoutError(err), errors.Is(err, context.DeadlineExceeded):
+		return ErrorTypeTimeout
+	case errors.Is(err, kerrors.ErrRemoteOrNetwork), errors.Is(err, kerrors.ErrGetConnection):
+		return ErrorTypeTransport
+	case errors.Is(err, kerrors.ErrBiz), !kerrors.IsKitexError(err):
+		return ErrorTypeBizError
+	default:
+		return ErrorTypeInternal
+	}
+}
+
+// setErrorTags marks span as failed and logs err following the OpenTracing semantic conventions.
+func setErrorTags(span opentracing.Span, errType string, err error) {
+	ext.Error.Set(span, true)
+	span.SetTag(tagErrorType, errType)
+
+	var bizErr bizStatusError
+	if errors.As(err, &bizErr) {
+		span.SetTag(tagBizStatusCode, bizErr.BizStatusCode())
+		span.SetTag(tagBizStatusMessage, bizErr.BizMessage())
+	}
+
+	fields := []tracerLog.Field{
+		tracerLog.String(logEvent, "error"),
+		tracerLog.String(logErrorKind, errType),
+		tracerLog.String(logMessage, err.Error()),
+		tracerLog.Error(err),
+	}
+	var se stackError
+	if errors.As(err, &se) && se.Stack() != "" {
+		fields = append(fields, tracerLog.String(logStack, se.Stack()))
+	}
+	span.LogFields(fields...)
+}
+
+// recordRPCError tags span with the panic or error recorded in st, if any.
+func recordRPCError(span opentracing.Span, st rpcinfo.RPCStats) {
+	if panicked, panicInfo := st.Panicked(); panicked {
+		err, ok := panicInfo.(error)
+		if !ok {
+			err = fmt.Errorf("%v", panicInfo)
+		}
+		setErrorTags(span, ErrorTypePanic, err)
+		return
+	}
+	if err := st.Error(); err != nil {
+		setErrorTags(span, errorType(err), err)
+	}
+}
+
+// recordBizException tags span with the exception declared in IDL, if it's set in the result.
+func recordBizException(span opentracing.Span, result interface{}) {
+	if err := getBizException(result); err != nil {
+		setErrorTags(span, ErrorTypeBizException, err)
+	}
+}
+
+// getBizException returns the exception set in a generated thrift result struct.
+// The generated result holds a Success field and one pointer field per declared exception.
+func getBizException(result interface{}) error {
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if t.Field(i).Name == "Success" || f.Kind() != reflect.Ptr || f.IsNil() || !f.CanInterface() {
+			continue
+		}
+		if err, ok := f.Interface().(error); ok {
+			return err
+		}
+	}
+	return nil
+}
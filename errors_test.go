@@ -0,0 +1,99 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBizStatusError struct{}
+
+func (e *mockBizStatusError) Error() string        { return "biz status" }
+func (e *mockBizStatusError) BizStatusCode() int32 { return 1001 }
+func (e *mockBizStatusError) BizMessage() string   { return "order not found" }
+
+type mockException struct{}
+
+func (e *mockException) Error() string { return "exception" }
+
+type mockResult struct {
+	Success *string
+	Err1    *mockException
+}
+
+func Test_errorType(t *testing.T) {
+	convey.Convey("Test_errorType", t, func() {
+		assert.Equal(t, ErrorTypeTimeout, errorType(kerrors.ErrRPCTimeout.WithCause(errors.New("timeout"))))
+		assert.Equal(t, ErrorTypeTimeout, errorType(context.DeadlineExceeded))
+		assert.Equal(t, ErrorTypeTransport, errorType(kerrors.ErrRemoteOrNetwork.WithCause(errors.New("EOF"))))
+		assert.Equal(t, ErrorTypeTransport, errorType(kerrors.ErrGetConnection.WithCause(errors.New("refused"))))
+		assert.Equal(t, ErrorTypePanic, errorType(kerrors.ErrPanic.WithCause(errors.New("nil pointer"))))
+		assert.Equal(t, ErrorTypeBizError, errorType(kerrors.ErrBiz.WithCause(errors.New("biz"))))
+		assert.Equal(t, ErrorTypeBizError, errorType(errors.New("from middleware")))
+		assert.Equal(t, ErrorTypeBizStatus, errorType(&mockBizStatusError{}))
+		assert.Equal(t, ErrorTypeInternal, errorType(kerrors.ErrNoDestAddress))
+	})
+}
+
+func Test_setErrorTags(t *testing.T) {
+	convey.Convey("Test_setErrorTags", t, func() {
+		convey.Convey("biz status", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			err := &mockBizStatusError{}
+			setErrorTags(span, errorType(err), err)
+			assert.Equal(t, true, span.Tag("error"))
+			assert.Equal(t, ErrorTypeBizStatus, span.Tag(tagErrorType))
+			assert.Equal(t, int32(1001), span.Tag(tagBizStatusCode))
+			assert.Equal(t, "order not found", span.Tag(tagBizStatusMessage))
+			assert.Len(t, span.Logs(), 1)
+		})
+		convey.Convey("with stack", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			err := kerrors.ErrPanic.WithCauseAndStack(errors.New("boom"), "goroutine 1")
+			setErrorTags(span, ErrorTypePanic, err)
+			var stack string
+			for _, f := range span.Logs()[0].Fields {
+				if f.Key == logStack {
+					stack = f.ValueString
+				}
+			}
+			assert.Equal(t, "goroutine 1", stack)
+		})
+	})
+}
+
+func Test_getBizException(t *testing.T) {
+	convey.Convey("Test_getBizException", t, func() {
+		convey.Convey("exception set", func() {
+			ex := &mockException{}
+			assert.Equal(t, ex, getBizException(&mockResult{Err1: ex}))
+		})
+		convey.Convey("success", func() {
+			s := "ok"
+			assert.Nil(t, getBizException(&mockResult{Success: &s}))
+		})
+		convey.Convey("not a struct pointer", func() {
+			assert.Nil(t, getBizException(nil))
+			assert.Nil(t, getBizException(mockResult{}))
+		})
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go"
+	tracerLog "github.com/opentracing/opentracing-go/log"
+)
+
+// EventMode is how the stats events of the rpc, such as read and write, are recorded.
+type EventMode int
+
+// Event modes.
+const (
+	// EventModeSpans records each event group as a child span of the rpc span.
+	EventModeSpans EventMode = iota
+	// EventModeLogs records each event group as timestamped start and finish logs on the rpc span.
+	EventModeLogs
+	// EventModeTags records the duration of each event group as a `{group}_ms` tag on the rpc span.
+	EventModeTags
+	// EventModeOff doesn't record events.
+	EventModeOff
+)
+
+// EventGroup is a pair of start and finish stats events.
+type EventGroup string
+
+// Event groups, the connection group is recorded by the client and the handler group by the server.
+const (
+	EventGroupRead       EventGroup = "read"
+	EventGroupWaitRead   EventGroup = "wait_read"
+	EventGroupWrite      EventGroup = "write"
+	EventGroupConnection EventGroup = "connection"
+	EventGroupHandler    EventGroup = "handler"
+)
+
+// WithEventMode sets how the stats events of the rpc are recorded, EventModeSpans by default.
+func WithEventMode(mode EventMode) Option {
+	return func(o *options) {
+		o.eventMode = mode
+	}
+}
+
+// WithEventGroups records only the given event groups, all groups are recorded by default.
+func WithEventGroups(groups ...EventGroup) Option {
+	return func(o *options) {
+		o.eventGroups = make(map[EventGroup]bool, len(groups))
+		for _, g := range groups {
+			o.eventGroups[g] = true
+		}
+	}
+}
+
+// recordCommonEvents records the events shared by client and server.
+func (c *commonTracer) recordCommonEvents(span opentracing.Span, st rpcinfo.RPCStats, fo *opentracing.FinishOptions) {
+	c.recordEvent(span, fo, st, EventGroupRead, "read", stats.ReadStart, stats.ReadFinish, tracerLog.Uint64("recv_size", st.RecvSize()))
+	c.recordEvent(span, fo, st, EventGroupWaitRead, "wait_read", stats.WaitReadStart, stats.WaitReadFinish)
+	c.recordEvent(span, fo, st, EventGroupWrite, "write", stats.WriteStart, stats.WriteFinish, tracerLog.Uint64("send_size", st.SendSize()))
+}
+
+// recordEvent records the event group from start to end on span according to the event mode,
+// fields describe the group and are set as tags or logged with the finish event.
+// Logs are added to fo as they must be timestamped. Events are only recorded at the detailed stats level,
+// a group missing its start or end event is skipped.
+func (c *commonTracer) recordEvent(span opentracing.Span, fo *opentracing.FinishOptions, st rpcinfo.RPCStats,
+	group EventGroup, operationName string, start, end stats.Event, fields ...tracerLog.Field) {
+	if c.eventMode == EventModeOff || c.eventGroups != nil && !c.eventGroups[group] || st.Level() < stats.LevelDetailed {
+		return
+	}
+	startEvent, endEvent := st.GetEvent(start), st.GetEvent(end)
+	if startEvent == nil || endEvent == nil {
+		return
+	}
+	switch c.eventMode {
+	case EventModeSpans:
+		if eventSpan := c.newEventSpan(operationName, st, start, end, span.Context()); eventSpan != nil {
+			setFieldTags(eventSpan, fields)
+		}
+	case EventModeLogs:
+		fo.LogRecords = append(fo.LogRecords,
+			opentracing.LogRecord{
+				Timestamp: startEvent.Time(),
+				Fields:    []tracerLog.Field{tracerLog.String(logEvent, operationName+" start")},
+			},
+			opentracing.LogRecord{
+				Timestamp: endEvent.Time(),
+				Fields:    append([]tracerLog.Field{tracerLog.String(logEvent, operationName+" finish")}, fields...),
+			})
+	case EventModeTags:
+		duration := endEvent.Time().Sub(startEvent.Time())
+		span.SetTag(string(group)+"_ms", float64(duration)/float64(time.Millisecond))
+		setFieldTags(span, fields)
+	}
+}
+
+func setFieldTags(span opentracing.Span, fields []tracerLog.Field) {
+	for _, f := range fields {
+		span.SetTag(f.Key(), f.Value())
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+// finishTestClientSpan traces a client rpc which read and wrote a message with ct.
+func finishTestClientSpan(ct *clientTracer) {
+	ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+	ctx = ct.Start(ctx)
+	st := ri.Stats()
+	for _, e := range []stats.Event{stats.WriteStart, stats.WriteFinish, stats.ReadStart, stats.ReadFinish, stats.RPCFinish} {
+		st.Record(ctx, e, stats.StatusInfo, "")
+	}
+	rpcinfo.AsMutableRPCStats(st).SetRecvSize(128)
+	ct.Finish(ctx)
+}
+
+func Test_recordEvent(t *testing.T) {
+	convey.Convey("Test_recordEvent", t, func() {
+		convey.Convey("spans", func() {
+			tracer := mocktracer.New()
+			finishTestClientSpan(newTestClientTracer(WithTracer(tracer)))
+			spans := tracer.FinishedSpans()
+			assert.Len(t, spans, 3)
+			assert.Equal(t, "read", spans[0].OperationName)
+			assert.Equal(t, uint64(128), spans[0].Tag("recv_size"))
+			assert.Equal(t, "write", spans[1].OperationName)
+		})
+		convey.Convey("logs", func() {
+			tracer := mocktracer.New()
+			finishTestClientSpan(newTestClientTracer(WithTracer(tracer), WithEventMode(EventModeLogs)))
+			spans := tracer.FinishedSpans()
+			assert.Len(t, spans, 1)
+			logs := spans[0].Logs()
+			assert.Len(t, logs, 4)
+			assert.Equal(t, "read start", logField(logs[0], logEvent))
+			assert.Equal(t, "read finish", logField(logs[1], logEvent))
+			assert.Equal(t, "128", logField(logs[1], "recv_size"))
+			assert.False(t, logs[1].Timestamp.Before(logs[0].Timestamp))
+		})
+		convey.Convey("tags", func() {
+			tracer := mocktracer.New()
+			finishTestClientSpan(newTestClientTracer(WithTracer(tracer), WithEventMode(EventModeTags), WithEventGroups(EventGroupWrite)))
+			spans := tracer.FinishedSpans()
+			assert.Len(t, spans, 1)
+			assert.IsType(t, float64(0), spans[0].Tag("write_ms"))
+			assert.Nil(t, spans[0].Tag("read_ms"))
+		})
+		convey.Convey("off", func() {
+			tracer := mocktracer.New()
+			finishTestClientSpan(newTestClientTracer(WithTracer(tracer), WithEventMode(EventModeOff)))
+			spans := tracer.FinishedSpans()
+			assert.Len(t, spans, 1)
+			assert.Len(t, spans[0].Logs(), 0)
+		})
+	})
+}
+
+func Test_statsLevel(t *testing.T) {
+	convey.Convey("Test_statsLevel", t, func() {
+		trace := func(level stats.Level, events ...stats.Event) []*mocktracer.MockSpan {
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer))
+			ctx, ri := newTestRPCInfo(level)
+			ctx = ct.Start(ctx)
+			for _, e := range events {
+				ri.Stats().Record(ctx, e, stats.StatusInfo, "")
+			}
+			ct.Finish(ctx)
+			return tracer.FinishedSpans()
+		}
+		convey.Convey("disabled", func() {
+			assert.Len(t, trace(stats.LevelDisabled, stats.ReadStart, stats.ReadFinish, stats.RPCFinish), 0)
+		})
+		convey.Convey("base", func() {
+			spans := trace(stats.LevelBase, stats.ReadStart, stats.ReadFinish, stats.RPCFinish)
+			assert.Len(t, spans, 1)
+			assert.False(t, spans[0].FinishTime.IsZero())
+		})
+		convey.Convey("missing events", func() {
+			spans := trace(stats.LevelDetailed, stats.ReadStart, stats.WriteFinish)
+			assert.Len(t, spans, 1)
+			assert.False(t, spans[0].FinishTime.IsZero())
+		})
+	})
+}
+
+func Test_statsLevelDisabledServer(t *testing.T) {
+	convey.Convey("Test_statsLevelDisabledServer", t, func() {
+		tracer := mocktracer.New()
+		upstream := tracer.StartSpan("upstream").(*mocktracer.MockSpan)
+		ctx, ri := newTestRPCInfo(stats.LevelDisabled)
+		ctx, _ = TextMapPropagator().Inject(ctx, tracer, upstream.Context())
+		ctx = metainfo.TransferForward(ctx)
+
+		st := newTestServerTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()))
+		ctx = st.Start(ctx)
+		_ = SpanContextExtractMW(func(ctx context.Context, req, resp interface{}) error {
+			assert.Nil(t, ServerSpanFromContext(ctx))
+			// downstream rpcs of the handler stay in the trace
+			child, _ := StartChildSpan(ctx, "query")
+			assert.Equal(t, upstream.SpanContext.SpanID, child.(*mocktracer.MockSpan).ParentID)
+			return nil
+		})(ctx, nil, nil)
+		ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+		st.Finish(ctx)
+		assert.Len(t, tracer.FinishedSpans(), 0)
+
+		convey.Convey("extract middleware not run", func() {
+			ctx, ri := newTestRPCInfo(stats.LevelDisabled)
+			ctx = st.Start(ctx)
+			rpcinfo.AsMutableRPCStats(ri.Stats()).SetError(errors.New("forbidden"))
+			st.Finish(ctx)
+			assert.Len(t, tracer.FinishedSpans(), 0)
+		})
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// SkipMethods returns a Filter skipping the rpcs invoking one of methods.
+func SkipMethods(methods ...string) Filter {
+	set := stringSet(methods)
+	return func(ctx context.Context) bool {
+		ri := rpcinfo.GetRPCInfo(ctx)
+		return ri == nil || ri.Invocation() == nil || !set[ri.Invocation().MethodName()]
+	}
+}
+
+// SkipServices returns a Filter skipping the rpcs invoking one of services.
+func SkipServices(services ...string) Filter {
+	set := stringSet(services)
+	return func(ctx context.Context) bool {
+		ri := rpcinfo.GetRPCInfo(ctx)
+		return ri == nil || ri.Invocation() == nil || !set[ri.Invocation().ServiceName()]
+	}
+}
+
+// SkipCallers returns a Filter skipping the rpcs sent by one of the caller services.
+func SkipCallers(callers ...string) Filter {
+	set := stringSet(callers)
+	return func(ctx context.Context) bool {
+		ri := rpcinfo.GetRPCInfo(ctx)
+		return ri == nil || ri.From() == nil || !set[ri.From().ServiceName()]
+	}
+}
+
+// SkipMetainfo returns a Filter skipping the rpcs whose transient or persistent metainfo value of key is one of values,
+// or is set at all if no value is given.
+func SkipMetainfo(key string, values ...string) Filter {
+	set := stringSet(values)
+	return func(ctx context.Context) bool {
+		v, ok := metainfoValue(ctx, key)
+		if !ok {
+			// keys transited by HTTP2 are converted to CGI variables
+			v, ok = metainfoValue(ctx, metainfo.HTTPHeaderToCGIVariable(key))
+		}
+		return !ok || len(set) > 0 && !set[v]
+	}
+}
+
+func metainfoValue(ctx context.Context, key string) (string, bool) {
+	if v, ok := metainfo.GetValue(ctx, key); ok {
+		return v, true
+	}
+	return metainfo.GetPersistentValue(ctx, key)
+}
+
+func stringSet(ss []string) map[string]bool {
+	set := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		set[s] = true
+	}
+	return set
+}
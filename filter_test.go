@@ -0,0 +1,76 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Filters(t *testing.T) {
+	convey.Convey("Test_Filters", t, func() {
+		ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+		assert.False(t, SkipMethods("Ping", "Echo")(ctx))
+		assert.True(t, SkipMethods("Ping")(ctx))
+		assert.False(t, SkipServices("echo")(ctx))
+		assert.False(t, SkipCallers("client")(ctx))
+		assert.True(t, SkipCallers("batch")(ctx))
+
+		assert.True(t, SkipMetainfo("stress")(ctx))
+		assert.False(t, SkipMetainfo("stress")(metainfo.WithPersistentValue(ctx, "stress", "1")))
+		assert.False(t, SkipMetainfo("x-stress", "1")(metainfo.WithValue(ctx, "X_STRESS", "1")))
+		assert.True(t, SkipMetainfo("stress", "1")(metainfo.WithValue(ctx, "stress", "0")))
+
+		o := newOptions([]Option{WithFilter(SkipMethods("Ping")), WithFilter(SkipCallers("client"))})
+		assert.False(t, o.filter(ctx))
+	})
+}
+
+func Test_passThrough(t *testing.T) {
+	convey.Convey("Test_passThrough", t, func() {
+		tracer := mocktracer.New()
+		upstream := tracer.StartSpan("upstream").(*mocktracer.MockSpan)
+		ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+		ctx, _ = TextMapPropagator().Inject(ctx, tracer, upstream.Context())
+		ctx = metainfo.TransferForward(ctx)
+
+		st := newTestServerTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()), WithFilter(SkipMethods("Echo")))
+		ctx = st.Start(ctx)
+		_ = SpanContextExtractMW(func(ctx context.Context, req, resp interface{}) error {
+			// downstream rpc of the handler
+			ct := newTestClientTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()))
+			clientCtx := ct.Start(ctx)
+			span := clientSpanFromContext(clientCtx).(*mocktracer.MockSpan)
+			assert.Equal(t, upstream.SpanContext.SpanID, span.ParentID)
+			assert.Equal(t, upstream.SpanContext.TraceID, span.SpanContext.TraceID)
+
+			// skipped downstream rpc injects the upstream span context as is
+			ct = newTestClientTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()), WithFilter(SkipMethods("Echo")))
+			_ = SpanContextInjectMW(func(ctx context.Context, req, resp interface{}) error {
+				sc, err := TextMapPropagator().Extract(transit(ctx), tracer)
+				assert.Nil(t, err)
+				assert.Equal(t, upstream.SpanContext.SpanID, sc.(mocktracer.MockSpanContext).SpanID)
+				return nil
+			})(ct.Start(ctx), nil, &struct{}{})
+			return nil
+		})(ctx, nil, &struct{}{})
+	})
+}
@@ -0,0 +1,233 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Keys of the text formats understood when mapping span context between tracers and wire formats.
+const (
+	jaegerTraceIDKey    = "uber-trace-id"
+	jaegerBaggagePrefix = "uberctx-"
+	b3TraceIDKey        = "x-b3-traceid"
+	b3SpanIDKey         = "x-b3-spanid"
+	b3ParentSpanIDKey   = "x-b3-parentspanid"
+	b3SampledKey        = "x-b3-sampled"
+	b3FlagsKey          = "x-b3-flags"
+)
+
+const (
+	traceIDHexLength = 32
+	spanIDHexLength  = 16
+
+	jaegerFlagSampled = 1
+	jaegerFlagDebug   = 2
+)
+
+var errInvalidIdentity = errors.New("invalid trace identity")
+
+// traceIdentity is the tracer independent identity of a span context, ids are lower case hex strings.
+type traceIdentity struct {
+	traceID  string
+	spanID   string
+	parentID string
+	sampled  bool
+	debug    bool
+	baggage  map[string]string
+}
+
+func (id *traceIdentity) valid() bool {
+	return isHexID(id.traceID, traceIDHexLength) && isHexID(id.spanID, spanIDHexLength) &&
+		(id.parentID == "" || isHexID(id.parentID, spanIDHexLength))
+}
+
+// isHexID reports whether s is a non-zero hex id of at most max digits.
+func isHexID(s string, max int) bool {
+	if s == "" || len(s) > max {
+		return false
+	}
+	nonZero := false
+	for _, c := range s {
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			nonZero = true
+		default:
+			return false
+		}
+	}
+	return nonZero
+}
+
+// padHexID left pads s with zeros to n digits.
+func padHexID(s string, n int) string {
+	if len(s) >= n {
+		return s
+	}
+	return strings.Repeat("0", n-len(s)) + s
+}
+
+// identityFromCarrier decodes the identity from a carrier in the Jaeger or B3 text format.
+func identityFromCarrier(carrier opentracing.TextMapCarrier) (*traceIdentity, error) {
+	values := lowerKeys(carrier)
+	var id *traceIdentity
+	var err error
+	if v, ok := values[jaegerTraceIDKey]; ok {
+		id, err = parseJaegerTraceID(v)
+	} else if _, ok := values[b3TraceIDKey]; ok {
+		id, err = parseB3Headers(values)
+	} else {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range values {
+		if strings.HasPrefix(k, jaegerBaggagePrefix) && len(k) > len(jaegerBaggagePrefix) {
+			if id.baggage == nil {
+				id.baggage = make(map[string]string)
+			}
+			id.baggage[k[len(jaegerBaggagePrefix):]] = v
+		}
+	}
+	return id, nil
+}
+
+// lowerKeys returns a copy of carrier with lower case keys.
+func lowerKeys(carrier opentracing.TextMapCarrier) map[string]string {
+	values := make(map[string]string, len(carrier))
+	for k, v := range carrier {
+		values[strings.ToLower(k)] = v
+	}
+	return values
+}
+
+// parseJaegerTraceID parses the `{trace-id}:{span-id}:{parent-span-id}:{flags}` format.
+func parseJaegerTraceID(v string) (*traceIdentity, error) {
+	parts := strings.Split(v, ":")
+	if len(parts) != 4 {
+		return nil, errInvalidIdentity
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return nil, errInvalidIdentity
+	}
+	id := &traceIdentity{
+		traceID: strings.ToLower(parts[0]),
+		spanID:  strings.ToLower(parts[1]),
+		sampled: flags&jaegerFlagSampled != 0,
+		debug:   flags&jaegerFlagDebug != 0,
+	}
+	if p := strings.ToLower(parts[2]); p != "0" {
+		id.parentID = p
+	}
+	if !id.valid() {
+		return nil, errInvalidIdentity
+	}
+	return id, nil
+}
+
+// parseB3Headers parses the multi header B3 format, keys of values must be lower case.
+func parseB3Headers(values map[string]string) (*traceIdentity, error) {
+	id := &traceIdentity{
+		traceID:  strings.ToLower(values[b3TraceIDKey]),
+		spanID:   strings.ToLower(values[b3SpanIDKey]),
+		parentID: strings.ToLower(values[b3ParentSpanIDKey]),
+		debug:    values[b3FlagsKey] == "1",
+	}
+	sampled := values[b3SampledKey]
+	id.sampled = id.debug || sampled == "1" || sampled == "true"
+	if !id.valid() {
+		return nil, errInvalidIdentity
+	}
+	return id, nil
+}
+
+// jaegerCarrier encodes id in the Jaeger text format.
+func (id *traceIdentity) jaegerCarrier() opentracing.TextMapCarrier {
+	var flags uint64
+	if id.sampled {
+		flags |= jaegerFlagSampled
+	}
+	if id.debug {
+		flags |= jaegerFlagDebug
+	}
+	parentID := id.parentID
+	if parentID == "" {
+		parentID = "0"
+	}
+	carrier := opentracing.TextMapCarrier{
+		jaegerTraceIDKey: id.traceID + ":" + id.spanID + ":" + parentID + ":" + strconv.FormatUint(flags, 16),
+	}
+	for k, v := range id.baggage {
+		carrier[jaegerBaggagePrefix+k] = v
+	}
+	return carrier
+}
+
+// b3Carrier encodes id in the multi header B3 format.
+func (id *traceIdentity) b3Carrier() opentracing.TextMapCarrier {
+	carrier := opentracing.TextMapCarrier{
+		b3TraceIDKey: id.traceID,
+		b3SpanIDKey:  id.spanID,
+	}
+	if id.parentID != "" {
+		carrier[b3ParentSpanIDKey] = id.parentID
+	}
+	if id.debug {
+		carrier[b3FlagsKey] = "1"
+	} else if id.sampled {
+		carrier[b3SampledKey] = "1"
+	} else {
+		carrier[b3SampledKey] = "0"
+	}
+	return carrier
+}
+
+// identityFromTracer maps sc to its identity through the TextMap format of tracer,
+// which must be the Jaeger or B3 text format.
+func identityFromTracer(tracer opentracing.Tracer, sc opentracing.SpanContext) (*traceIdentity, error) {
+	carrier := opentracing.TextMapCarrier{}
+	if err := tracer.Inject(sc, opentracing.TextMap, carrier); err != nil {
+		return nil, err
+	}
+	id, err := identityFromCarrier(carrier)
+	if err != nil {
+		return nil, err
+	}
+	sc.ForeachBaggageItem(func(k, v string) bool {
+		if id.baggage == nil {
+			id.baggage = make(map[string]string)
+		}
+		id.baggage[k] = v
+		return true
+	})
+	return id, nil
+}
+
+// spanContextFromIdentity maps id to a span context of tracer through its TextMap format,
+// the Jaeger text format is tried first and then the B3 one.
+func spanContextFromIdentity(tracer opentracing.Tracer, id *traceIdentity) (opentracing.SpanContext, error) {
+	sc, err := tracer.Extract(opentracing.TextMap, id.jaegerCarrier())
+	if err == nil && sc != nil {
+		return sc, nil
+	}
+	return tracer.Extract(opentracing.TextMap, id.b3Carrier())
+}
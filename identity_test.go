@@ -0,0 +1,119 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+// textTracer is a minimal tracer speaking the Jaeger or B3 text format, depending on b3.
+type textTracer struct {
+	opentracing.NoopTracer
+	b3 bool
+}
+
+type textSpanContext struct {
+	id *traceIdentity
+}
+
+func (c textSpanContext) ForeachBaggageItem(handler func(k, v string) bool) {}
+
+func (t textTracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	c := t.carrier(sc.(textSpanContext).id)
+	w := carrier.(opentracing.TextMapWriter)
+	return c.ForeachKey(func(k, v string) error {
+		w.Set(k, v)
+		return nil
+	})
+}
+
+func (t textTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	c := opentracing.TextMapCarrier{}
+	_ = carrier.(opentracing.TextMapReader).ForeachKey(func(k, v string) error {
+		c[k] = v
+		return nil
+	})
+	if _, ok := c[b3TraceIDKey]; ok != t.b3 {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	id, err := identityFromCarrier(c)
+	if err != nil {
+		return nil, err
+	}
+	return textSpanContext{id}, nil
+}
+
+func (t textTracer) carrier(id *traceIdentity) opentracing.TextMapCarrier {
+	if t.b3 {
+		return id.b3Carrier()
+	}
+	return id.jaegerCarrier()
+}
+
+func Test_identityFromCarrier(t *testing.T) {
+	convey.Convey("Test_identityFromCarrier", t, func() {
+		convey.Convey("jaeger", func() {
+			id, err := identityFromCarrier(opentracing.TextMapCarrier{
+				"Uber-Trace-Id":  "abc:def:0:3",
+				"uberctx-tenant": "t1",
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, &traceIdentity{traceID: "abc", spanID: "def", sampled: true, debug: true,
+				baggage: map[string]string{"tenant": "t1"}}, id)
+		})
+		convey.Convey("b3", func() {
+			id, err := identityFromCarrier(opentracing.TextMapCarrier{
+				"X-B3-TraceId":      "463ac35c9f6413ad48485a3953bb6124",
+				"X-B3-SpanId":       "a2fb4a1d1a96d312",
+				"X-B3-ParentSpanId": "0020000000000001",
+				"X-B3-Sampled":      "1",
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, &traceIdentity{traceID: "463ac35c9f6413ad48485a3953bb6124", spanID: "a2fb4a1d1a96d312",
+				parentID: "0020000000000001", sampled: true}, id)
+		})
+		convey.Convey("not found", func() {
+			_, err := identityFromCarrier(opentracing.TextMapCarrier{"foo": "bar"})
+			assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+		})
+		convey.Convey("invalid", func() {
+			_, err := identityFromCarrier(opentracing.TextMapCarrier{jaegerTraceIDKey: "0:1:0:1"})
+			assert.Equal(t, errInvalidIdentity, err)
+			_, err = identityFromCarrier(opentracing.TextMapCarrier{jaegerTraceIDKey: "xyz:1:0:1"})
+			assert.Equal(t, errInvalidIdentity, err)
+			_, err = identityFromCarrier(opentracing.TextMapCarrier{b3TraceIDKey: "1"})
+			assert.Equal(t, errInvalidIdentity, err)
+		})
+	})
+}
+
+func Test_spanContextFromIdentity(t *testing.T) {
+	convey.Convey("Test_spanContextFromIdentity", t, func() {
+		id := &traceIdentity{traceID: "abc", spanID: "def", sampled: true}
+		for _, tracer := range []textTracer{{b3: false}, {b3: true}} {
+			sc, err := spanContextFromIdentity(tracer, id)
+			assert.Nil(t, err)
+			assert.Equal(t, id, sc.(textSpanContext).id)
+
+			got, err := identityFromTracer(tracer, sc)
+			assert.Nil(t, err)
+			assert.Equal(t, id, got)
+		}
+	})
+}
@@ -15,40 +15,99 @@
 package opentracing
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"errors"
-	"fmt"
 
-	"github.com/bytedance/gopkg/cloud/metainfo"
 	"github.com/cloudwego/kitex/pkg/endpoint"
 	"github.com/cloudwego/kitex/pkg/rpcinfo"
-	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/cloudwego/kitex/pkg/streaming"
 	"github.com/opentracing/opentracing-go"
 )
 
 const (
 	// SpanContextKey transit key, transited by HTTP2 will convert case, so use uppercase
 	SpanContextKey = "JAEGERSPANCONTEXT"
+
+	tagExtractError = "kitex.propagation.extract_error"
 )
 
 type opentracingCtx int
 
 const (
 	traceContainerKey opentracingCtx = iota
+	clientContainerKey
+	passThroughKey
 )
 
+// passThrough holds the span context received by a server which skipped tracing the rpc,
+// so that it's passed to the downstream rpcs and the trace stays connected.
+type passThrough struct {
+	tracer      opentracing.Tracer
+	spanContext opentracing.SpanContext
+}
+
+// passThroughFromContext returns the span context passed through by a skipped server span, if any.
+func passThroughFromContext(ctx context.Context) *passThrough {
+	pt, _ := ctx.Value(passThroughKey).(*passThrough)
+	return pt
+}
+
+// SpanContextInjectMW injects the span context of ctx into metainfo with the propagator of the client suite.
 func SpanContextInjectMW(next endpoint.Endpoint) endpoint.Endpoint {
 	return func(ctx context.Context, req, resp interface{}) (err error) {
-		span := opentracing.SpanFromContext(ctx)
-		var b bytes.Buffer
-		span.Tracer().Inject(span.Context(), opentracing.Binary, &b)
-		ctx = metainfo.WithValue(ctx, SpanContextKey, base64.StdEncoding.EncodeToString(b.Bytes()))
-		return next(ctx, req, resp)
+		var tracer opentracing.Tracer
+		var spanContext opentracing.SpanContext
+		if span := opentracing.SpanFromContext(ctx); span != nil {
+			tracer, spanContext = span.Tracer(), span.Context()
+		} else if pt := passThroughFromContext(ctx); pt != nil {
+			tracer, spanContext = pt.tracer, pt.spanContext
+		} else {
+			return next(ctx, req, resp)
+		}
+		propagator := BinaryPropagator()
+		cc, _ := ctx.Value(clientContainerKey).(*clientContainer)
+		if cc != nil {
+			propagator = cc.clientTracer.propagator
+			if bridge := cc.clientTracer.baggageBridge; bridge != nil {
+				ctx = bridge.baggageToMetainfo(ctx, spanContext)
+			}
+		}
+		// propagation failures must not fail the rpc
+		ctx, _ = propagator.Inject(ctx, tracer, spanContext)
+		var capture *payloadCapture
+		var attempt *rpcAttempt
+		if cc != nil && cc.span != nil {
+			// the endpoint is called once per attempt of retries and backup requests
+			attempt = cc.startAttempt()
+			if isOneway(req, resp) {
+				// the span finishes as soon as the request is sent, the server works after it
+				cc.span.SetTag(tagOneway, true)
+			}
+			// the request is the same for every attempt, it's logged once
+			if capture = cc.clientTracer.capturePayload(ctx, req, resp); capture != nil && attempt.number == 1 {
+				capture.log(ctx, cc.span, payloadRequest, req)
+			}
+		}
+		err = next(ctx, req, resp)
+		if attempt == nil {
+			return err
+		}
+		// a backup request returning after the rpc must leave the finished span alone
+		if recorded := cc.finishAttempt(attempt, rpcinfo.GetRPCInfo(ctx), err); err == nil && recorded {
+			if capture != nil && resp != nil {
+				capture.log(ctx, cc.span, payloadResponse, resp)
+			}
+			if result, ok := resp.(*streaming.Result); ok && result.Stream != nil {
+				result.Stream = newTracedStream(result.Stream, cc.span)
+			} else {
+				recordBizException(cc.span, resp)
+			}
+		}
+		return err
 	}
 }
 
+// SpanContextExtractMW starts the server span as a child of the span context extracted from metainfo.
 func SpanContextExtractMW(next endpoint.Endpoint) endpoint.Endpoint {
 	return func(ctx context.Context, req, resp interface{}) error {
 		tc, ok := ctx.Value(traceContainerKey).(*traceContainer)
@@ -56,31 +115,43 @@ func SpanContextExtractMW(next endpoint.Endpoint) endpoint.Endpoint {
 			return errors.New("no opentracing tracer found in context")
 		}
 		svrTracer := tc.serverTracer
-		var operationName string
-		if svrTracer.formOperationName != nil {
-			operationName = svrTracer.formOperationName(ctx)
-		}
-
-		var opts []opentracing.StartSpanOption
-
-		ri := rpcinfo.GetRPCInfo(ctx)
-		startTime := ri.Stats().GetEvent(stats.RPCStart).Time()
-		opts = append(opts, opentracing.StartTime(startTime))
-
-		if sck, ok := metainfo.GetValue(ctx, SpanContextKey); ok {
-			binaryBytes, err := base64.StdEncoding.DecodeString(sck)
-			if err != nil {
-				return fmt.Errorf("decode opentracing binary failed, %w", err)
+		opts, ok := svrTracer.startOptions(ctx)
+		if !ok {
+			tc.filtered = true
+			if parentContext, err := svrTracer.propagator.Extract(ctx, svrTracer.tracer); err == nil {
+				ctx = context.WithValue(ctx, passThroughKey, &passThrough{tracer: svrTracer.tracer, spanContext: parentContext})
 			}
-			parentContext, err := svrTracer.tracer.Extract(opentracing.Binary, bytes.NewBuffer(binaryBytes))
-			if err != nil {
-				return fmt.Errorf("extract SpanContext failed, %w", err)
+			return next(ctx, req, resp)
+		}
+		rpcSpan, ctx := svrTracer.startSpan(ctx, isOneway(req, resp), opts...)
+		tc.span = rpcSpan
+		if args, ok := req.(*streaming.Args); ok && args.Stream != nil {
+			stream := newTracedStream(args.Stream, rpcSpan)
+			// the handler only gets the stream context, which must lead to the server span and tracer too
+			stream.ctx = context.WithValue(stream.ctx, traceContainerKey, tc)
+			args.Stream = stream
+		}
+		capture := svrTracer.capturePayload(ctx, req, resp)
+		if capture != nil {
+			capture.log(ctx, rpcSpan, payloadRequest, req)
+		}
+		err := next(ctx, req, resp)
+		if err == nil {
+			if capture != nil && resp != nil {
+				capture.log(ctx, rpcSpan, payloadResponse, resp)
 			}
-			opts = append(opts, opentracing.ChildOf(parentContext))
+			recordBizException(rpcSpan, resp)
 		}
+		return err
+	}
+}
 
-		rpcSpan, ctx := opentracing.StartSpanFromContextWithTracer(ctx, svrTracer.tracer, operationName, opts...)
-		tc.span = rpcSpan
-		return next(ctx, req, resp)
+// isOneway reports whether the rpc is a oneway call, rpcinfo doesn't tell it
+// but kitex passes no response to the endpoints of oneway methods, unlike streaming calls.
+func isOneway(req, resp interface{}) bool {
+	if resp != nil {
+		return false
 	}
+	_, isStream := req.(*streaming.Args)
+	return !isStream
 }
@@ -0,0 +1,102 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServerTracer(opts ...Option) *serverTracer {
+	st := &serverTracer{}
+	st.options = newOptions(opts)
+	return st
+}
+
+// refTracer records the reference types of the spans it starts.
+type refTracer struct {
+	*mocktracer.MockTracer
+	refs []opentracing.SpanReferenceType
+}
+
+func (t *refTracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	sso := opentracing.StartSpanOptions{}
+	for _, o := range opts {
+		o.Apply(&sso)
+	}
+	for _, ref := range sso.References {
+		t.refs = append(t.refs, ref.Type)
+	}
+	return t.MockTracer.StartSpan(operationName, opts...)
+}
+
+func Test_SpanContextExtractMW(t *testing.T) {
+	convey.Convey("Test_SpanContextExtractMW", t, func() {
+		convey.Convey("corrupt span context", func() {
+			tracer := mocktracer.New()
+			var handled error
+			var caller rpcinfo.EndpointInfo
+			st := newTestServerTracer(WithTracer(tracer), WithExtractErrorHandler(func(ctx context.Context, from rpcinfo.EndpointInfo, err error) {
+				caller, handled = from, err
+			}))
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = metainfo.WithValue(ctx, SpanContextKey, "!!!")
+			ctx = st.Start(ctx)
+			err := SpanContextExtractMW(func(ctx context.Context, req, resp interface{}) error {
+				return nil
+			})(ctx, nil, nil)
+			assert.Nil(t, err)
+			assert.NotNil(t, handled)
+			assert.Equal(t, "client", caller.ServiceName())
+
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			st.Finish(ctx)
+			spans := tracer.FinishedSpans()
+			assert.Len(t, spans, 1)
+			assert.Equal(t, 0, spans[0].ParentID)
+			assert.Equal(t, handled.Error(), spans[0].Tag(tagExtractError))
+		})
+		convey.Convey("oneway", func() {
+			tracer := &refTracer{MockTracer: mocktracer.New()}
+			ct := newTestClientTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()))
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			var clientCtx context.Context
+			_ = SpanContextInjectMW(func(ctx context.Context, req, resp interface{}) error {
+				clientCtx = ctx
+				return nil
+			})(ctx, nil, nil)
+			assert.Equal(t, true, clientSpanFromContext(ctx).(*mocktracer.MockSpan).Tag(tagOneway))
+
+			st := newTestServerTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()))
+			ctx, _ = newTestRPCInfo(stats.LevelDetailed)
+			ctx = metainfo.TransferForward(metainfo.SetMetaInfoFromMap(ctx, toMetaMap(clientCtx)))
+			ctx = st.Start(ctx)
+			_ = SpanContextExtractMW(func(ctx context.Context, req, resp interface{}) error {
+				assert.Equal(t, true, opentracing.SpanFromContext(ctx).(*mocktracer.MockSpan).Tag(tagOneway))
+				return nil
+			})(ctx, nil, nil)
+			assert.Equal(t, []opentracing.SpanReferenceType{opentracing.FollowsFromRef}, tracer.refs)
+		})
+	})
+}
@@ -17,6 +17,7 @@ package opentracing
 
 import (
 	"context"
+	"time"
 
 	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/cloudwego/kitex/pkg/stats"
@@ -24,35 +25,89 @@ import (
 )
 
 type commonTracer struct {
-	tracer            opentracing.Tracer
-	formOperationName func(context.Context) string
+	*options
 }
 
-func (c *commonTracer) newCommonSpan(span opentracing.Span, st rpcinfo.RPCStats) {
-	readSpan := c.newEventSpan("read", st, stats.ReadStart, stats.ReadFinish, span.Context())
-	if readSpan != nil {
-		readSpan.SetTag("recv_size", st.RecvSize())
+func (c *commonTracer) operationName(ctx context.Context) string {
+	if c.formOperationName != nil {
+		return c.formOperationName(ctx)
 	}
+	return ""
+}
+
+// startOptions reports whether the rpc should be traced, and the options to start its span with.
+// Nothing is traced when the stats of the rpc are disabled, Kitex keeps the level of the rpcinfo
+// the server reuses on a connection across rpcs.
+func (c *commonTracer) startOptions(ctx context.Context) ([]opentracing.StartSpanOption, bool) {
+	if ri := rpcinfo.GetRPCInfo(ctx); ri != nil && ri.Stats() != nil && ri.Stats().Level() == stats.LevelDisabled {
+		return nil, false
+	}
+	if c.filter != nil && !c.filter(ctx) {
+		return nil, false
+	}
+	policy := c.samplingPolicy
+	var tags opentracing.Tags
+	if cfg := c.configStore.load(); cfg != nil {
+		if cfg.skip(ctx) {
+			return nil, false
+		}
+		if cfg.samplingPolicy != nil {
+			policy = cfg.samplingPolicy
+		}
+		tags = cfg.tags
+	}
+	var opts []opentracing.StartSpanOption
+	if policy != nil {
+		var ok bool
+		if opts, ok = policy.samplingOptions(ctx); !ok {
+			return nil, false
+		}
+	}
+	if tags != nil {
+		opts = append(opts, tags)
+	}
+	return opts, true
+}
+
+// capturePayload returns the payloadCapture logging the payloads of the rpc in ctx, nil if they aren't captured.
+func (c *commonTracer) capturePayload(ctx context.Context, req, resp interface{}) *payloadCapture {
+	if c.payloadCapture == nil || !c.payloadCapture.enabled(ctx, req, resp) {
+		return nil
+	}
+	return c.payloadCapture
+}
+
+// rpcFinishTime returns the time of the RPCFinish event, or now if it isn't recorded.
+func rpcFinishTime(st rpcinfo.RPCStats) time.Time {
+	if event := st.GetEvent(stats.RPCFinish); event != nil {
+		return event.Time()
+	}
+	return time.Now()
+}
+
+func (c *commonTracer) decorateStart(ctx context.Context, span opentracing.Span, ri rpcinfo.RPCInfo) {
+	if c.onStart != nil {
+		c.onStart(ctx, span, ri)
+	}
+}
 
-	c.newEventSpan("wait_read", st, stats.WaitReadStart, stats.WaitReadFinish, span.Context())
-	writeSpan := c.newEventSpan("write", st, stats.WriteStart, stats.WriteFinish, span.Context())
-	if writeSpan != nil {
-		writeSpan.SetTag("send_size", st.SendSize())
+func (c *commonTracer) decorateFinish(ctx context.Context, span opentracing.Span, ri rpcinfo.RPCInfo) {
+	if c.onFinish != nil {
+		c.onFinish(ctx, span, ri)
 	}
 }
 
 func (c *commonTracer) newEventSpan(operationName string, st rpcinfo.RPCStats, start, end stats.Event, parentContext opentracing.SpanContext) opentracing.Span {
 	var opts []opentracing.StartSpanOption
-	event := st.GetEvent(start)
-	if event == nil {
+	startEvent, endEvent := st.GetEvent(start), st.GetEvent(end)
+	if startEvent == nil || endEvent == nil {
 		return nil
 	}
-	startTime := opentracing.StartTime(event.Time())
-	opts = append(opts, opentracing.StartTime(startTime))
+	opts = append(opts, opentracing.StartTime(startEvent.Time()))
 	if parentContext != nil {
 		opts = append(opts, opentracing.ChildOf(parentContext))
 	}
 	span := c.tracer.StartSpan(operationName, opts...)
-	span.FinishWithOptions(opentracing.FinishOptions{FinishTime: st.GetEvent(end).Time()})
+	span.FinishWithOptions(opentracing.FinishOptions{FinishTime: endEvent.Time()})
 	return span
 }
@@ -0,0 +1,41 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"net"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+)
+
+// newTestRPCInfo returns a ctx holding a rpcinfo of a call from client to echo::Echo,
+// with RPCStart recorded.
+func newTestRPCInfo(level stats.Level) (context.Context, rpcinfo.RPCInfo) {
+	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:8888")
+	st := rpcinfo.NewRPCStats()
+	rpcinfo.AsMutableRPCStats(st).SetLevel(level)
+	ri := rpcinfo.NewRPCInfo(
+		rpcinfo.NewEndpointInfo("client", "Call", nil, nil),
+		rpcinfo.NewEndpointInfo("echo", "Echo", addr, nil),
+		rpcinfo.NewInvocation("echo", "Echo"),
+		rpcinfo.NewRPCConfig(),
+		st,
+	)
+	ctx := rpcinfo.NewCtxWithRPCInfo(context.Background(), ri)
+	st.Record(ctx, stats.RPCStart, stats.StatusInfo, "")
+	return ctx, ri
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/transport"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Option configures the client and server suites.
+type Option func(o *options)
+
+// SpanDecorator enriches the rpc span, ri gives access to the endpoints and stats of the rpc and ctx to its metainfo.
+type SpanDecorator func(ctx context.Context, span opentracing.Span, ri rpcinfo.RPCInfo)
+
+// Filter reports whether the rpc should be traced, return false to skip span creation.
+type Filter func(ctx context.Context) bool
+
+// ExtractErrorHandler is called by the server when the incoming span context is corrupt,
+// caller is the offending caller, its ServiceName and Address tell where it comes from.
+type ExtractErrorHandler func(ctx context.Context, caller rpcinfo.EndpointInfo, err error)
+
+type options struct {
+	tracer              opentracing.Tracer
+	formOperationName   func(context.Context) string
+	onStart             SpanDecorator
+	onFinish            SpanDecorator
+	filter              Filter
+	propagator          Propagator
+	baggageBridge       *BaggageBridge
+	extractErrorHandler ExtractErrorHandler
+	transportProtocol   *transport.Protocol
+	samplingPolicy      *SamplingPolicy
+	configStore         *ConfigStore
+	payloadCapture      *payloadCapture
+	eventMode           EventMode
+	eventGroups         map[EventGroup]bool
+	instanceTags        []string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		tracer:            opentracing.GlobalTracer(),
+		formOperationName: defaultOperationName,
+		propagator:        BinaryPropagator(),
+		instanceTags:      defaultInstanceTags,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// defaultOperationName uses `{Service Name}::{Method Name}` as operation name.
+func defaultOperationName(ctx context.Context) string {
+	endpoint := rpcinfo.GetRPCInfo(ctx).From()
+	return endpoint.ServiceName() + "::" + endpoint.Method()
+}
+
+// WithTracer sets the tracer used to create spans, opentracing global tracer is used by default.
+func WithTracer(tracer opentracing.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithOperationNameFunc sets the operation name formatter, `{Service Name}::{Method Name}` is used by default.
+func WithOperationNameFunc(formOperationName func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.formOperationName = formOperationName
+	}
+}
+
+// WithSpanDecorator sets a decorator called on the rpc span before it finishes, it's the same as WithOnFinish.
+func WithSpanDecorator(decorator SpanDecorator) Option {
+	return WithOnFinish(decorator)
+}
+
+// WithOnStart sets a decorator called on the rpc span once it's started,
+// the events and the error of the rpc aren't recorded in the stats yet.
+func WithOnStart(decorator SpanDecorator) Option {
+	return func(o *options) {
+		o.onStart = decorator
+	}
+}
+
+// WithOnFinish sets a decorator called on the rpc span before it finishes.
+func WithOnFinish(decorator SpanDecorator) Option {
+	return func(o *options) {
+		o.onFinish = decorator
+	}
+}
+
+// WithFilter adds a filter deciding whether the rpc should be traced, the rpc is traced only if all the filters return true.
+// A skipped rpc has no span, but the span context it received is still passed to the downstream rpcs.
+func WithFilter(filter Filter) Option {
+	return func(o *options) {
+		if prev := o.filter; prev != nil {
+			o.filter = func(ctx context.Context) bool {
+				return prev(ctx) && filter(ctx)
+			}
+			return
+		}
+		o.filter = filter
+	}
+}
+
+// WithPropagator sets the propagator transiting span context, BinaryPropagator is used by default.
+func WithPropagator(propagator Propagator) Option {
+	return func(o *options) {
+		o.propagator = propagator
+	}
+}
+
+// WithExtractErrorHandler sets a handler called when the server fails to extract the incoming span context,
+// e.g. to count the failures per caller:
+//
+//	WithExtractErrorHandler(func(ctx context.Context, caller rpcinfo.EndpointInfo, err error) {
+//		extractErrors.WithLabelValues(caller.ServiceName()).Inc()
+//	})
+//
+// The rpc is traced as a new root span in that case.
+func WithExtractErrorHandler(handler ExtractErrorHandler) Option {
+	return func(o *options) {
+		o.extractErrorHandler = handler
+	}
+}
+
+// WithTransportProtocol sets the transport protocol of the client, which is kept by default.
+// Span context is transited in TTHeader or in gRPC metadata, so it must not be PurePayload or Framed alone.
+// It's ignored by the server suite which detects the protocol of each connection.
+func WithTransportProtocol(protocol transport.Protocol) Option {
+	return func(o *options) {
+		o.transportProtocol = &protocol
+	}
+}
+
+// WithInstanceTags sets the discovery instance tags copied to the client span as `kitex.peer.{key}`,
+// "weight" copies the weight of the instance. cluster, idc, env and weight are copied by default, no key disables it.
+func WithInstanceTags(keys ...string) Option {
+	return func(o *options) {
+		o.instanceTags = keys
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/streaming"
+	"github.com/opentracing/opentracing-go"
+	tracerLog "github.com/opentracing/opentracing-go/log"
+)
+
+const (
+	logPayload          = "payload"
+	logPayloadTruncated = "payload.truncated"
+
+	payloadRequest  = "request"
+	payloadResponse = "response"
+
+	redacted = "[REDACTED]"
+)
+
+// Payload formats of PayloadCapture.
+const (
+	PayloadFormatJSON = "json"
+	PayloadFormatText = "text"
+)
+
+// Redactor scrubs sensitive data from a formatted payload before it's logged.
+type Redactor func(ctx context.Context, payload string) string
+
+// PayloadCapture configures the logging of the request and response payloads on the rpc span.
+type PayloadCapture struct {
+	// Methods are the methods whose payloads are captured, all methods if empty.
+	Methods []string
+	// Sampler captures the payloads of the sampled rpcs only, all rpcs if nil.
+	Sampler Sampler
+	// Format is PayloadFormatJSON or PayloadFormatText (`%+v`), PayloadFormatJSON by default.
+	Format string
+	// MaxSize truncates the payloads longer than it, 0 means no limit.
+	MaxSize int
+	// Redact is called on each formatted payload before it's truncated and logged.
+	Redact Redactor
+}
+
+// WithPayloadCapture enables the logging of the request and response payloads on the rpc span.
+// Payloads may hold sensitive data, use Redact to scrub it.
+func WithPayloadCapture(capture PayloadCapture) Option {
+	return func(o *options) {
+		c := &payloadCapture{PayloadCapture: capture}
+		if len(capture.Methods) > 0 {
+			c.methods = stringSet(capture.Methods)
+		}
+		o.payloadCapture = c
+	}
+}
+
+type payloadCapture struct {
+	PayloadCapture
+	methods map[string]bool
+}
+
+// enabled reports whether the payloads of the rpc in ctx are captured, streaming payloads are never captured.
+func (c *payloadCapture) enabled(ctx context.Context, req, resp interface{}) bool {
+	if _, ok := req.(*streaming.Args); ok {
+		return false
+	}
+	if _, ok := resp.(*streaming.Result); ok {
+		return false
+	}
+	if c.methods != nil {
+		ri := rpcinfo.GetRPCInfo(ctx)
+		if ri == nil || ri.Invocation() == nil || !c.methods[ri.Invocation().MethodName()] {
+			return false
+		}
+	}
+	return c.Sampler == nil || c.Sampler.Sample(ctx)
+}
+
+// log logs payload on span as the event request or response.
+func (c *payloadCapture) log(ctx context.Context, span opentracing.Span, event string, payload interface{}) {
+	s := c.format(payload)
+	if c.Redact != nil {
+		s = c.Redact(ctx, s)
+	}
+	fields := []tracerLog.Field{tracerLog.String(logEvent, event)}
+	if c.MaxSize > 0 && len(s) > c.MaxSize {
+		s = strings.ToValidUTF8(s[:c.MaxSize], "")
+		fields = append(fields, tracerLog.Bool(logPayloadTruncated, true))
+	}
+	span.LogFields(append(fields, tracerLog.String(logPayload, s))...)
+}
+
+func (c *payloadCapture) format(payload interface{}) string {
+	if c.Format != PayloadFormatText {
+		if b, err := json.Marshal(payload); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%+v", payload)
+}
+
+// RedactJSONFields returns a Redactor replacing the values of the JSON object fields named one of fields,
+// at any depth, with "[REDACTED]". Payloads which are not valid JSON are redacted entirely.
+func RedactJSONFields(fields ...string) Redactor {
+	set := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		set[strings.ToLower(f)] = true
+	}
+	return func(ctx context.Context, payload string) string {
+		var v interface{}
+		if err := json.Unmarshal([]byte(payload), &v); err != nil {
+			return redacted
+		}
+		b, err := json.Marshal(redactJSON(v, set))
+		if err != nil {
+			return redacted
+		}
+		return string(b)
+	}
+}
+
+func redactJSON(v interface{}, fields map[string]bool) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, fv := range vv {
+			if fields[strings.ToLower(k)] {
+				vv[k] = redacted
+			} else {
+				vv[k] = redactJSON(fv, fields)
+			}
+		}
+	case []interface{}:
+		for i, ev := range vv {
+			vv[i] = redactJSON(ev, fields)
+		}
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func Test_payloadCapture(t *testing.T) {
+	convey.Convey("Test_payloadCapture", t, func() {
+		convey.Convey("client request and response", func() {
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()), WithPayloadCapture(PayloadCapture{
+				Redact: RedactJSONFields("Password"),
+			}))
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			_ = SpanContextInjectMW(func(ctx context.Context, req, resp interface{}) error {
+				resp.(*mockRequest).User = "bob"
+				return nil
+			})(ctx, &mockRequest{User: "alice", Password: "secret"}, &mockRequest{})
+
+			logs := clientSpanFromContext(ctx).(*mocktracer.MockSpan).Logs()
+			assert.Len(t, logs, 2)
+			assert.Equal(t, payloadRequest, logField(logs[0], logEvent))
+			assert.Equal(t, `{"password":"[REDACTED]","user":"alice"}`, logField(logs[0], logPayload))
+			assert.Equal(t, payloadResponse, logField(logs[1], logEvent))
+			assert.Equal(t, `{"password":"[REDACTED]","user":"bob"}`, logField(logs[1], logPayload))
+		})
+		convey.Convey("retried rpc", func() {
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()), WithPayloadCapture(PayloadCapture{}))
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			req := &mockRequest{User: "alice"}
+			_ = SpanContextInjectMW(func(ctx context.Context, req, resp interface{}) error {
+				return kerrors.ErrRPCTimeout
+			})(ctx, req, &mockRequest{})
+			_ = SpanContextInjectMW(func(ctx context.Context, req, resp interface{}) error {
+				return nil
+			})(ctx, req, &mockRequest{})
+
+			logs := clientSpanFromContext(ctx).(*mocktracer.MockSpan).Logs()
+			assert.Len(t, logs, 2)
+			assert.Equal(t, payloadRequest, logField(logs[0], logEvent))
+			assert.Equal(t, payloadResponse, logField(logs[1], logEvent))
+		})
+		convey.Convey("method not enabled", func() {
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			c := &payloadCapture{methods: stringSet([]string{"Ping"})}
+			assert.False(t, c.enabled(ctx, &mockRequest{}, &mockRequest{}))
+			c = &payloadCapture{PayloadCapture: PayloadCapture{Sampler: NeverSample()}}
+			assert.False(t, c.enabled(ctx, &mockRequest{}, &mockRequest{}))
+		})
+		convey.Convey("text and truncation", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			c := &payloadCapture{PayloadCapture: PayloadCapture{Format: PayloadFormatText, MaxSize: 12}}
+			c.log(context.Background(), span, payloadRequest, &mockRequest{User: "alice", Password: "secret"})
+			assert.Equal(t, "&{User:alice", logField(span.Logs()[0], logPayload))
+			assert.Equal(t, "true", logField(span.Logs()[0], logPayloadTruncated))
+		})
+		convey.Convey("redact invalid json", func() {
+			assert.Equal(t, redacted, RedactJSONFields("password")(context.Background(), "&{Password:secret}"))
+		})
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Propagator injects span context into and extracts span context from Kitex metainfo.
+type Propagator interface {
+	// Inject returns a context carrying sc in its metainfo.
+	Inject(ctx context.Context, tracer opentracing.Tracer, sc opentracing.SpanContext) (context.Context, error)
+	// Extract returns the span context carried in the metainfo of ctx,
+	// or opentracing.ErrSpanContextNotFound if there is none.
+	Extract(ctx context.Context, tracer opentracing.Tracer) (opentracing.SpanContext, error)
+}
+
+// BinaryPropagator returns a Propagator transiting the opentracing.Binary format of span context
+// as a base64 string under SpanContextKey, both sides must use tracers with the same binary codec.
+// It's the default propagator of the suites.
+func BinaryPropagator() Propagator {
+	return binaryPropagator{}
+}
+
+// TextMapPropagator returns a Propagator transiting each key of the opentracing.TextMap format
+// of span context as its own metainfo entry.
+func TextMapPropagator() Propagator {
+	return carrierPropagator{format: opentracing.TextMap}
+}
+
+// HTTPHeadersPropagator returns a Propagator transiting each key of the opentracing.HTTPHeaders
+// format of span context as its own metainfo entry.
+func HTTPHeadersPropagator() Propagator {
+	return carrierPropagator{format: opentracing.HTTPHeaders}
+}
+
+type binaryPropagator struct{}
+
+func (binaryPropagator) Inject(ctx context.Context, tracer opentracing.Tracer, sc opentracing.SpanContext) (context.Context, error) {
+	var b bytes.Buffer
+	if err := tracer.Inject(sc, opentracing.Binary, &b); err != nil {
+		return ctx, err
+	}
+	return metainfo.WithValue(ctx, SpanContextKey, base64.StdEncoding.EncodeToString(b.Bytes())), nil
+}
+
+func (binaryPropagator) Extract(ctx context.Context, tracer opentracing.Tracer) (opentracing.SpanContext, error) {
+	sck, ok := metainfo.GetValue(ctx, SpanContextKey)
+	if !ok {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	binaryBytes, err := base64.StdEncoding.DecodeString(sck)
+	if err != nil {
+		return nil, fmt.Errorf("decode opentracing binary failed, %w", err)
+	}
+	return tracer.Extract(opentracing.Binary, bytes.NewBuffer(binaryBytes))
+}
+
+type carrierPropagator struct {
+	format opentracing.BuiltinFormat
+}
+
+func (p carrierPropagator) Inject(ctx context.Context, tracer opentracing.Tracer, sc opentracing.SpanContext) (context.Context, error) {
+	carrier := opentracing.TextMapCarrier{}
+	if err := tracer.Inject(sc, p.format, carrier); err != nil {
+		return ctx, err
+	}
+	for k, v := range carrier {
+		ctx = metainfo.WithValue(ctx, k, v)
+	}
+	return ctx, nil
+}
+
+func (p carrierPropagator) Extract(ctx context.Context, tracer opentracing.Tracer) (opentracing.SpanContext, error) {
+	carrier := metainfoCarrier(ctx)
+	if len(carrier) == 0 {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	return tracer.Extract(p.format, carrier)
+}
+
+// metainfoCarrier returns the transient metainfo values of ctx as a carrier.
+// Keys transited by HTTP2 are converted to CGI variables, e.g. `uber-trace-id` arrives as `UBER_TRACE_ID`,
+// so the HTTP header form of each key is added as well.
+func metainfoCarrier(ctx context.Context) opentracing.TextMapCarrier {
+	values := metainfo.GetAllValues(ctx)
+	carrier := make(opentracing.TextMapCarrier, len(values))
+	for k, v := range values {
+		carrier[k] = v
+		if hk := metainfo.CGIVariableToHTTPHeader(k); hk != k {
+			if _, ok := values[hk]; !ok {
+				carrier[hk] = v
+			}
+		}
+	}
+	return carrier
+}
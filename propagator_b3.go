@@ -0,0 +1,124 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+const b3SingleHeaderKey = "b3"
+
+// B3Propagator returns a Propagator transiting span context in the multi header B3 format,
+// as `x-b3-traceid`, `x-b3-spanid`, `x-b3-parentspanid`, `x-b3-sampled` and `x-b3-flags` metainfo entries.
+// Both the multi and the single header format are accepted on extraction.
+// The span context is mapped through the TextMap format of the tracer like W3CPropagator.
+func B3Propagator() Propagator {
+	return b3Propagator{}
+}
+
+// B3SingleHeaderPropagator returns a Propagator transiting span context in the single header B3 format,
+// as the `b3` metainfo entry. Both the multi and the single header format are accepted on extraction.
+func B3SingleHeaderPropagator() Propagator {
+	return b3Propagator{singleHeader: true}
+}
+
+type b3Propagator struct {
+	singleHeader bool
+}
+
+func (p b3Propagator) Inject(ctx context.Context, tracer opentracing.Tracer, sc opentracing.SpanContext) (context.Context, error) {
+	id, err := identityFromTracer(tracer, sc)
+	if err != nil {
+		return ctx, err
+	}
+	if p.singleHeader {
+		return metainfo.WithValue(ctx, b3SingleHeaderKey, id.b3SingleHeader()), nil
+	}
+	for k, v := range id.b3Carrier() {
+		ctx = metainfo.WithValue(ctx, k, v)
+	}
+	return ctx, nil
+}
+
+func (p b3Propagator) Extract(ctx context.Context, tracer opentracing.Tracer) (opentracing.SpanContext, error) {
+	values := lowerKeys(metainfoCarrier(ctx))
+	var id *traceIdentity
+	var err error
+	if v, ok := values[b3SingleHeaderKey]; ok {
+		id, err = parseB3SingleHeader(v)
+	} else if _, ok := values[b3TraceIDKey]; ok {
+		id, err = parseB3Headers(values)
+	} else {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spanContextFromIdentity(tracer, id)
+}
+
+// b3SingleHeader encodes id in the `{trace-id}-{span-id}-{sampling}-{parent-span-id}` format.
+func (id *traceIdentity) b3SingleHeader() string {
+	sampling := "0"
+	if id.debug {
+		sampling = "d"
+	} else if id.sampled {
+		sampling = "1"
+	}
+	v := id.traceID + "-" + id.spanID + "-" + sampling
+	if id.parentID != "" {
+		v += "-" + id.parentID
+	}
+	return v
+}
+
+// parseB3SingleHeader parses the `{trace-id}-{span-id}-{sampling}-{parent-span-id}` format,
+// the last two fields are optional. A header carrying only the sampling decision has no span context.
+func parseB3SingleHeader(v string) (*traceIdentity, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(v)), "-")
+	if len(parts) == 1 {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	if len(parts) > 4 {
+		return nil, errInvalidIdentity
+	}
+	id := &traceIdentity{
+		traceID: parts[0],
+		spanID:  parts[1],
+	}
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "1":
+			id.sampled = true
+		case "d":
+			id.sampled = true
+			id.debug = true
+		case "0":
+		default:
+			return nil, errInvalidIdentity
+		}
+	}
+	if len(parts) > 3 {
+		id.parentID = parts[3]
+	}
+	if !id.valid() {
+		return nil, errInvalidIdentity
+	}
+	return id, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestB3Propagator(t *testing.T) {
+	convey.Convey("TestB3Propagator", t, func() {
+		tracer := textTracer{}
+		sc := textSpanContext{&traceIdentity{traceID: "463ac35c9f6413ad", spanID: "a2fb4a1d1a96d312", parentID: "1", sampled: true}}
+		convey.Convey("inject multi header", func() {
+			ctx, err := B3Propagator().Inject(context.Background(), tracer, sc)
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{
+				b3TraceIDKey:      "463ac35c9f6413ad",
+				b3SpanIDKey:       "a2fb4a1d1a96d312",
+				b3ParentSpanIDKey: "1",
+				b3SampledKey:      "1",
+			}, metainfo.GetAllValues(ctx))
+		})
+		convey.Convey("inject single header", func() {
+			ctx, err := B3SingleHeaderPropagator().Inject(context.Background(), tracer, sc)
+			assert.Nil(t, err)
+			v, _ := metainfo.GetValue(ctx, b3SingleHeaderKey)
+			assert.Equal(t, "463ac35c9f6413ad-a2fb4a1d1a96d312-1-1", v)
+		})
+		convey.Convey("extract multi header from gateway", func() {
+			ctx := metainfo.WithValue(context.Background(), "X-B3-TraceId", "463ac35c9f6413ad")
+			ctx = metainfo.WithValue(ctx, "X-B3-SpanId", "a2fb4a1d1a96d312")
+			ctx = metainfo.WithValue(ctx, "X-B3-Sampled", "1")
+			got, err := B3SingleHeaderPropagator().Extract(ctx, tracer)
+			assert.Nil(t, err)
+			assert.Equal(t, &traceIdentity{traceID: "463ac35c9f6413ad", spanID: "a2fb4a1d1a96d312", sampled: true},
+				got.(textSpanContext).id)
+		})
+		convey.Convey("extract single header transited by HTTP2", func() {
+			ctx := metainfo.WithValue(context.Background(), "B3", "463ac35c9f6413ad-a2fb4a1d1a96d312-d")
+			got, err := B3Propagator().Extract(ctx, tracer)
+			assert.Nil(t, err)
+			assert.Equal(t, &traceIdentity{traceID: "463ac35c9f6413ad", spanID: "a2fb4a1d1a96d312", sampled: true, debug: true},
+				got.(textSpanContext).id)
+		})
+		convey.Convey("extract not found", func() {
+			_, err := B3Propagator().Extract(context.Background(), tracer)
+			assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+			_, err = B3Propagator().Extract(metainfo.WithValue(context.Background(), b3SingleHeaderKey, "0"), tracer)
+			assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+		})
+	})
+}
+
+func Test_parseB3SingleHeader(t *testing.T) {
+	convey.Convey("Test_parseB3SingleHeader", t, func() {
+		id, err := parseB3SingleHeader("80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90")
+		assert.Nil(t, err)
+		assert.Equal(t, &traceIdentity{traceID: "80f198ee56343ba864fe8b2a57d3eff7", spanID: "e457b5a2e4d86bd1",
+			parentID: "05e3ac9a4f6e3b90", sampled: true}, id)
+		assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90", id.b3SingleHeader())
+
+		for _, v := range []string{"a-b-x", "a-b-1-c-d", "0-b", "a-zz"} {
+			_, err = parseB3SingleHeader(v)
+			assert.Equal(t, errInvalidIdentity, err, v)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+// JaegerPropagator returns a Propagator transiting span context in the Jaeger text format,
+// as the `uber-trace-id` metainfo entry and one `uberctx-{key}` entry per baggage item,
+// which is understood by Jaeger clients of all languages.
+// Span context carried under the legacy SpanContextKey is extracted when `uber-trace-id` is absent,
+// use CompositePropagator(JaegerPropagator(), BinaryPropagator()) to inject both during rollout.
+// The span context is mapped through the TextMap format of the tracer like W3CPropagator.
+func JaegerPropagator() Propagator {
+	return jaegerPropagator{}
+}
+
+type jaegerPropagator struct{}
+
+func (jaegerPropagator) Inject(ctx context.Context, tracer opentracing.Tracer, sc opentracing.SpanContext) (context.Context, error) {
+	id, err := identityFromTracer(tracer, sc)
+	if err != nil {
+		return ctx, err
+	}
+	for k, v := range id.jaegerCarrier() {
+		ctx = metainfo.WithValue(ctx, k, v)
+	}
+	return ctx, nil
+}
+
+func (jaegerPropagator) Extract(ctx context.Context, tracer opentracing.Tracer) (opentracing.SpanContext, error) {
+	values := lowerKeys(metainfoCarrier(ctx))
+	if _, ok := values[jaegerTraceIDKey]; !ok {
+		return BinaryPropagator().Extract(ctx, tracer)
+	}
+	id, err := identityFromCarrier(values)
+	if err != nil {
+		return nil, err
+	}
+	return spanContextFromIdentity(tracer, id)
+}
+
+// CompositePropagator returns a Propagator injecting span context with all propagators,
+// and extracting it with the first propagator finding one in metainfo.
+func CompositePropagator(propagators ...Propagator) Propagator {
+	return compositePropagator(propagators)
+}
+
+type compositePropagator []Propagator
+
+func (ps compositePropagator) Inject(ctx context.Context, tracer opentracing.Tracer, sc opentracing.SpanContext) (context.Context, error) {
+	var firstErr error
+	for _, p := range ps {
+		injected, err := p.Inject(ctx, tracer, sc)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		ctx = injected
+	}
+	return ctx, firstErr
+}
+
+func (ps compositePropagator) Extract(ctx context.Context, tracer opentracing.Tracer) (opentracing.SpanContext, error) {
+	firstErr := opentracing.ErrSpanContextNotFound
+	for _, p := range ps {
+		sc, err := p.Extract(ctx, tracer)
+		if err == nil {
+			return sc, nil
+		}
+		if firstErr == opentracing.ErrSpanContextNotFound {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
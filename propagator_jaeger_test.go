@@ -0,0 +1,85 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaegerPropagator(t *testing.T) {
+	convey.Convey("TestJaegerPropagator", t, func() {
+		tracer := textTracer{}
+		convey.Convey("inject", func() {
+			sc := textSpanContext{&traceIdentity{traceID: "abc", spanID: "def", sampled: true,
+				baggage: map[string]string{"tenant": "t1"}}}
+			ctx, err := JaegerPropagator().Inject(context.Background(), tracer, sc)
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{
+				jaegerTraceIDKey: "abc:def:0:1",
+				"uberctx-tenant": "t1",
+			}, metainfo.GetAllValues(ctx))
+		})
+		convey.Convey("extract transited by HTTP2", func() {
+			ctx := metainfo.WithValue(context.Background(), "UBER_TRACE_ID", "abc:def:0:1")
+			ctx = metainfo.WithValue(ctx, "UBERCTX_TENANT", "t1")
+			sc, err := JaegerPropagator().Extract(ctx, tracer)
+			assert.Nil(t, err)
+			assert.Equal(t, &traceIdentity{traceID: "abc", spanID: "def", sampled: true,
+				baggage: map[string]string{"tenant": "t1"}}, sc.(textSpanContext).id)
+		})
+		convey.Convey("extract falls back to binary", func() {
+			_, err := JaegerPropagator().Extract(context.Background(), tracer)
+			assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+
+			ctx := metainfo.WithValue(context.Background(), SpanContextKey, "!!!")
+			_, err = JaegerPropagator().Extract(ctx, tracer)
+			assert.NotNil(t, err)
+			assert.NotEqual(t, opentracing.ErrSpanContextNotFound, err)
+		})
+	})
+}
+
+func TestCompositePropagator(t *testing.T) {
+	convey.Convey("TestCompositePropagator", t, func() {
+		tracer := textTracer{}
+		p := CompositePropagator(W3CPropagator(), JaegerPropagator())
+		convey.Convey("inject all", func() {
+			sc := textSpanContext{&traceIdentity{traceID: "abc", spanID: "def"}}
+			ctx, err := p.Inject(context.Background(), tracer, sc)
+			assert.Nil(t, err)
+			_, ok := metainfo.GetValue(ctx, w3cTraceParentKey)
+			assert.True(t, ok)
+			_, ok = metainfo.GetValue(ctx, jaegerTraceIDKey)
+			assert.True(t, ok)
+		})
+		convey.Convey("extract first found", func() {
+			ctx := metainfo.WithValue(context.Background(), jaegerTraceIDKey, "abc:def:0:1")
+			sc, err := p.Extract(ctx, tracer)
+			assert.Nil(t, err)
+			assert.Equal(t, "def", sc.(textSpanContext).id.spanID)
+		})
+		convey.Convey("extract error", func() {
+			ctx := metainfo.WithValue(context.Background(), w3cTraceParentKey, "invalid")
+			_, err := p.Extract(ctx, tracer)
+			assert.Equal(t, errInvalidIdentity, err)
+		})
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+// transit simulates the server side of a call, which sees the transient values of the client as upstream values.
+func transit(ctx context.Context) context.Context {
+	return metainfo.TransferForward(metainfo.SetMetaInfoFromMap(context.Background(), toMetaMap(ctx)))
+}
+
+func toMetaMap(ctx context.Context) map[string]string {
+	m := map[string]string{}
+	metainfo.SaveMetaInfoToMap(ctx, m)
+	return m
+}
+
+func TestPropagators(t *testing.T) {
+	convey.Convey("TestPropagators", t, func() {
+		for name, p := range map[string]Propagator{
+			"TextMap":     TextMapPropagator(),
+			"HTTPHeaders": HTTPHeadersPropagator(),
+		} {
+			p := p
+			convey.Convey(name+" round trip", func() {
+				tracer := mocktracer.New()
+				span := tracer.StartSpan("client").(*mocktracer.MockSpan)
+				ctx, err := p.Inject(context.Background(), tracer, span.Context())
+				assert.Nil(t, err)
+				assert.True(t, len(metainfo.GetAllValues(ctx)) > 0)
+
+				sc, err := p.Extract(transit(ctx), tracer)
+				assert.Nil(t, err)
+				assert.Equal(t, span.SpanContext.TraceID, sc.(mocktracer.MockSpanContext).TraceID)
+				assert.Equal(t, span.SpanContext.SpanID, sc.(mocktracer.MockSpanContext).SpanID)
+			})
+			convey.Convey(name+" not found", func() {
+				_, err := p.Extract(context.Background(), mocktracer.New())
+				assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+			})
+		}
+		convey.Convey("HTTP2 converted keys", func() {
+			tracer := mocktracer.New()
+			span := tracer.StartSpan("client").(*mocktracer.MockSpan)
+			carrier := opentracing.TextMapCarrier{}
+			_ = tracer.Inject(span.Context(), opentracing.TextMap, carrier)
+			ctx := context.Background()
+			for k, v := range carrier {
+				ctx = metainfo.WithValue(ctx, metainfo.HTTPHeaderToCGIVariable(k), v)
+			}
+			sc, err := TextMapPropagator().Extract(ctx, tracer)
+			assert.Nil(t, err)
+			assert.Equal(t, span.SpanContext.SpanID, sc.(mocktracer.MockSpanContext).SpanID)
+		})
+		convey.Convey("Binary not found", func() {
+			_, err := BinaryPropagator().Extract(context.Background(), mocktracer.New())
+			assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+		})
+		convey.Convey("Binary corrupt", func() {
+			ctx := metainfo.WithValue(context.Background(), SpanContextKey, "!!!")
+			_, err := BinaryPropagator().Extract(ctx, mocktracer.New())
+			assert.NotNil(t, err)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	w3cTraceParentKey = "traceparent"
+	w3cTraceStateKey  = "tracestate"
+
+	w3cVersion        = "00"
+	w3cFlagSampled    = 1
+	w3cTraceParentLen = 55
+)
+
+// W3CPropagator returns a Propagator transiting span context in the W3C Trace Context format,
+// as `traceparent` and `tracestate` metainfo entries.
+// The span context is mapped to and from the W3C format through the TextMap format of the tracer,
+// which must be the Jaeger (uber-trace-id) or B3 (X-B3-*) text format.
+// The incoming tracestate is kept as the `tracestate` baggage item, so it's forwarded to downstream.
+func W3CPropagator() Propagator {
+	return w3cPropagator{}
+}
+
+type w3cPropagator struct{}
+
+func (w3cPropagator) Inject(ctx context.Context, tracer opentracing.Tracer, sc opentracing.SpanContext) (context.Context, error) {
+	id, err := identityFromTracer(tracer, sc)
+	if err != nil {
+		return ctx, err
+	}
+	flags := "00"
+	if id.sampled {
+		flags = "01"
+	}
+	traceParent := w3cVersion + "-" + padHexID(id.traceID, traceIDHexLength) + "-" + padHexID(id.spanID, spanIDHexLength) + "-" + flags
+	ctx = metainfo.WithValue(ctx, w3cTraceParentKey, traceParent)
+	if ts := id.baggage[w3cTraceStateKey]; ts != "" {
+		ctx = metainfo.WithValue(ctx, w3cTraceStateKey, ts)
+	}
+	return ctx, nil
+}
+
+func (w3cPropagator) Extract(ctx context.Context, tracer opentracing.Tracer) (opentracing.SpanContext, error) {
+	carrier := metainfoCarrier(ctx)
+	traceParent, ok := carrier[w3cTraceParentKey]
+	if !ok {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	id, err := parseTraceParent(traceParent)
+	if err != nil {
+		return nil, err
+	}
+	if ts := carrier[w3cTraceStateKey]; ts != "" {
+		id.baggage = map[string]string{w3cTraceStateKey: ts}
+	}
+	return spanContextFromIdentity(tracer, id)
+}
+
+// parseTraceParent parses the `{version}-{trace-id}-{parent-id}-{trace-flags}` format.
+// Versions above 00 may append fields, which are ignored.
+func parseTraceParent(v string) (*traceIdentity, error) {
+	v = strings.TrimSpace(v)
+	parts := strings.Split(v, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return nil, errInvalidIdentity
+	}
+	if parts[0] == w3cVersion && len(v) != w3cTraceParentLen {
+		return nil, errInvalidIdentity
+	}
+	if len(parts[1]) != traceIDHexLength || len(parts[2]) != spanIDHexLength || len(parts[3]) != 2 {
+		return nil, errInvalidIdentity
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return nil, errInvalidIdentity
+	}
+	id := &traceIdentity{
+		traceID: strings.ToLower(parts[1]),
+		spanID:  strings.ToLower(parts[2]),
+		sampled: flags&w3cFlagSampled != 0,
+	}
+	if !id.valid() {
+		return nil, errInvalidIdentity
+	}
+	return id, nil
+}
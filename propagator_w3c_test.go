@@ -0,0 +1,78 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/opentracing/opentracing-go"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestW3CPropagator(t *testing.T) {
+	convey.Convey("TestW3CPropagator", t, func() {
+		tracer := textTracer{}
+		convey.Convey("inject", func() {
+			sc := textSpanContext{&traceIdentity{traceID: "abc", spanID: "def", sampled: true,
+				baggage: map[string]string{w3cTraceStateKey: "vendor=1"}}}
+			ctx, err := W3CPropagator().Inject(context.Background(), tracer, sc)
+			assert.Nil(t, err)
+			tp, _ := metainfo.GetValue(ctx, w3cTraceParentKey)
+			assert.Equal(t, "00-00000000000000000000000000000abc-0000000000000def-01", tp)
+			ts, _ := metainfo.GetValue(ctx, w3cTraceStateKey)
+			assert.Equal(t, "vendor=1", ts)
+		})
+		convey.Convey("extract", func() {
+			ctx := metainfo.WithValue(context.Background(), "TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+			ctx = metainfo.WithValue(ctx, "TRACESTATE", "congo=t61rcWkgMzE")
+			sc, err := W3CPropagator().Extract(ctx, tracer)
+			assert.Nil(t, err)
+			assert.Equal(t, &traceIdentity{traceID: "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7",
+				baggage: map[string]string{w3cTraceStateKey: "congo=t61rcWkgMzE"}}, sc.(textSpanContext).id)
+		})
+		convey.Convey("extract not found", func() {
+			_, err := W3CPropagator().Extract(context.Background(), tracer)
+			assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+		})
+	})
+}
+
+func Test_parseTraceParent(t *testing.T) {
+	convey.Convey("Test_parseTraceParent", t, func() {
+		id, err := parseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		assert.Nil(t, err)
+		assert.True(t, id.sampled)
+
+		id, err = parseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+		assert.Nil(t, err)
+		assert.Equal(t, "00f067aa0ba902b7", id.spanID)
+
+		for _, v := range []string{
+			"",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+		} {
+			_, err = parseTraceParent(v)
+			assert.Equal(t, errInvalidIdentity, err, v)
+		}
+	})
+}
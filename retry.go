@@ -0,0 +1,182 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	tagRetryCount    = "kitex.retry.count"
+	tagRetryWinner   = "kitex.retry.winner"
+	tagAttempt       = "kitex.attempt"
+	tagAttemptType   = "kitex.attempt.type"
+	tagAttemptWinner = "kitex.attempt.winner"
+
+	attemptFirst  = "first"
+	attemptRetry  = "retry"
+	attemptBackup = "backup"
+)
+
+// rpcAttempt is one call of the rpc endpoint, retries and backup requests call it several times for an rpc.
+type rpcAttempt struct {
+	number int
+	kind   string
+	start  time.Time
+	end    time.Time
+	peer   *peerSnapshot
+	err    error
+	done   bool
+}
+
+// attempts records the attempts of an rpc. The attempt spans are only created once the rpc
+// is known to have several attempts, which run concurrently for backup requests.
+type attempts struct {
+	sync.Mutex
+	started   []*rpcAttempt
+	completed []*rpcAttempt
+	// finished is set when the rpc span is finished, attempts completing afterwards are backup requests which lost,
+	// they are dropped as the rpc span can't have children once finished
+	finished bool
+}
+
+// startAttempt records the start of an attempt, it's a backup request if an earlier attempt is still running.
+func (cc *clientContainer) startAttempt() *rpcAttempt {
+	cc.attempts.Lock()
+	defer cc.attempts.Unlock()
+	a := &rpcAttempt{number: len(cc.attempts.started) + 1, kind: attemptFirst, start: time.Now()}
+	if a.number > 1 {
+		a.kind = attemptRetry
+		for _, prev := range cc.attempts.started {
+			if !prev.done {
+				a.kind = attemptBackup
+			}
+		}
+	}
+	cc.attempts.started = append(cc.attempts.started, a)
+	return a
+}
+
+// finishAttempt records the end of attempt, ri is the rpcinfo of the attempt.
+// It reports false if the rpc span is already finished, the attempt is then dropped.
+func (cc *clientContainer) finishAttempt(a *rpcAttempt, ri rpcinfo.RPCInfo, err error) bool {
+	cc.attempts.Lock()
+	defer cc.attempts.Unlock()
+	a.end, a.err, a.done = time.Now(), err, true
+	if cc.attempts.finished {
+		return false
+	}
+	if ri != nil && ri.To() != nil {
+		// ri is recycled once the attempt is done
+		a.peer = newPeerSnapshot(ri.To(), cc.clientTracer.instanceTags)
+	}
+	cc.attempts.completed = append(cc.attempts.completed, a)
+	return true
+}
+
+// finishAttempts creates the spans of the completed attempts and tags the rpc span with the retry count,
+// nothing is recorded for an rpc with a single attempt. It returns the remote endpoint of the winning attempt,
+// nil if the rpc has a single attempt or none won.
+func (cc *clientContainer) finishAttempts() rpcinfo.EndpointInfo {
+	cc.attempts.Lock()
+	defer cc.attempts.Unlock()
+	cc.attempts.finished = true
+	if len(cc.attempts.started) <= 1 {
+		return nil
+	}
+	cc.span.SetTag(tagRetryCount, len(cc.attempts.started)-1)
+	winner := cc.attempts.winner()
+	if winner != nil {
+		cc.span.SetTag(tagRetryWinner, winner.number)
+	}
+	for _, a := range cc.attempts.completed {
+		cc.newAttemptSpan(a, a == winner)
+	}
+	if winner == nil || winner.peer == nil {
+		return nil
+	}
+	return winner.peer
+}
+
+// winner returns the attempt whose result is returned by the rpc: the first completed one for backup requests,
+// the last one for failure retries.
+func (as *attempts) winner() *rpcAttempt {
+	backup := false
+	for _, a := range as.started {
+		backup = backup || a.kind == attemptBackup
+	}
+	if !backup {
+		if last := as.started[len(as.started)-1]; last.done {
+			return last
+		}
+		return nil
+	}
+	for _, a := range as.completed {
+		// later responses of backup requests are dropped with ErrRPCFinish
+		if !errors.Is(a.err, kerrors.ErrRPCFinish) {
+			return a
+		}
+	}
+	return nil
+}
+
+// peerSnapshot copies the remote endpoint of an attempt with the instance tags to record,
+// as the rpcinfo of the attempt is recycled once it's done.
+type peerSnapshot struct {
+	rpcinfo.EndpointInfo
+	instance discovery.Instance
+}
+
+func newPeerSnapshot(to rpcinfo.EndpointInfo, keys []string) *peerSnapshot {
+	tags := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := to.Tag(k); ok {
+			tags[k] = v
+		}
+	}
+	p := &peerSnapshot{EndpointInfo: rpcinfo.NewEndpointInfo(to.ServiceName(), to.Method(), to.Address(), tags)}
+	if ig, ok := to.(instanceGetter); ok {
+		p.instance = ig.GetInstance()
+	}
+	return p
+}
+
+// GetInstance implements the instanceGetter interface.
+func (p *peerSnapshot) GetInstance() discovery.Instance {
+	return p.instance
+}
+
+func (cc *clientContainer) newAttemptSpan(a *rpcAttempt, winner bool) {
+	span := cc.clientTracer.tracer.StartSpan("attempt", opentracing.ChildOf(cc.span.Context()), opentracing.StartTime(a.start))
+	span.SetTag(tagAttempt, a.number)
+	span.SetTag(tagAttemptType, a.kind)
+	if winner {
+		span.SetTag(tagAttemptWinner, true)
+	}
+	if a.peer != nil {
+		setPeerAddrTags(span, a.peer.Address())
+	}
+	if a.err != nil {
+		setErrorTags(span, errorType(a.err), a.err)
+	}
+	span.FinishWithOptions(opentracing.FinishOptions{FinishTime: a.end})
+}
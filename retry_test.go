@@ -0,0 +1,143 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/rpcinfo/remoteinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func findSpans(tracer *mocktracer.MockTracer, operationName string) []*mocktracer.MockSpan {
+	var spans []*mocktracer.MockSpan
+	for _, span := range tracer.FinishedSpans() {
+		if span.OperationName == operationName {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func Test_attempts(t *testing.T) {
+	convey.Convey("Test_attempts", t, func() {
+		tracer := mocktracer.New()
+		ct := newTestClientTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()), WithEventMode(EventModeOff))
+		ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+		ctx = ct.Start(ctx)
+		call := func(ep endpoint.Endpoint) error {
+			return SpanContextInjectMW(ep)(ctx, nil, &struct{}{})
+		}
+
+		convey.Convey("single attempt", func() {
+			_ = call(func(ctx context.Context, req, resp interface{}) error { return nil })
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			assert.Len(t, tracer.FinishedSpans(), 1)
+			assert.Nil(t, tracer.FinishedSpans()[0].Tag(tagRetryCount))
+		})
+		convey.Convey("failure retry", func() {
+			_ = call(func(ctx context.Context, req, resp interface{}) error { return kerrors.ErrRPCTimeout })
+			_ = call(func(ctx context.Context, req, resp interface{}) error { return nil })
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+
+			rpcSpan := findSpans(tracer, "client::Call")[0]
+			assert.Equal(t, 1, rpcSpan.Tag(tagRetryCount))
+			assert.Equal(t, 2, rpcSpan.Tag(tagRetryWinner))
+			attempts := findSpans(tracer, "attempt")
+			assert.Len(t, attempts, 2)
+			assert.Equal(t, attemptFirst, attempts[0].Tag(tagAttemptType))
+			assert.Equal(t, ErrorTypeTimeout, attempts[0].Tag(tagErrorType))
+			assert.Equal(t, attemptRetry, attempts[1].Tag(tagAttemptType))
+			assert.Equal(t, true, attempts[1].Tag(tagAttemptWinner))
+			assert.Equal(t, rpcSpan.SpanContext.SpanID, attempts[1].ParentID)
+		})
+		convey.Convey("winning instance", func() {
+			_ = call(func(ctx context.Context, req, resp interface{}) error { return kerrors.ErrRPCTimeout })
+			// the retry gets its own rpcinfo, the load balancer picks another instance for it
+			to := remoteinfo.NewRemoteInfo(&rpcinfo.EndpointBasicInfo{ServiceName: "echo"}, "Echo")
+			to.SetInstance(discovery.NewInstance("tcp", "10.0.0.2:8888", 10, map[string]string{"idc": "lf"}))
+			retryRI := rpcinfo.NewRPCInfo(ri.From(), to, ri.Invocation(), ri.Config(), ri.Stats())
+			retryCtx := rpcinfo.NewCtxWithRPCInfo(ctx, retryRI)
+			_ = SpanContextInjectMW(func(ctx context.Context, req, resp interface{}) error { return nil })(retryCtx, nil, &struct{}{})
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+
+			rpcSpan := findSpans(tracer, "client::Call")[0]
+			assert.Equal(t, "10.0.0.2:8888", rpcSpan.Tag(tagPeerAddress))
+			assert.Equal(t, "lf", rpcSpan.Tag("kitex.peer.idc"))
+			assert.Equal(t, 10, rpcSpan.Tag("kitex.peer.weight"))
+		})
+		convey.Convey("backup request", func() {
+			started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+			go func() {
+				_ = call(func(ctx context.Context, req, resp interface{}) error {
+					close(started)
+					<-release
+					return kerrors.ErrRPCFinish
+				})
+				close(done)
+			}()
+			<-started
+			_ = call(func(ctx context.Context, req, resp interface{}) error { return nil })
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+
+			// the losing attempt completes after the rpc span is finished and is dropped
+			close(release)
+			<-done
+			attempts := findSpans(tracer, "attempt")
+			assert.Len(t, attempts, 1)
+			assert.Equal(t, attemptBackup, attempts[0].Tag(tagAttemptType))
+			assert.Equal(t, true, attempts[0].Tag(tagAttemptWinner))
+			assert.Equal(t, 2, findSpans(tracer, "client::Call")[0].Tag(tagRetryWinner))
+		})
+		convey.Convey("late attempt result", func() {
+			started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+			go func() {
+				_ = SpanContextInjectMW(func(ctx context.Context, req, resp interface{}) error {
+					close(started)
+					<-release
+					return nil
+				})(ctx, nil, &mockResult{Err1: &mockException{}})
+				close(done)
+			}()
+			<-started
+			_ = call(func(ctx context.Context, req, resp interface{}) error { return nil })
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+
+			// the exception of the losing attempt isn't recorded on the finished rpc span
+			close(release)
+			<-done
+			assert.Nil(t, findSpans(tracer, "client::Call")[0].Tag(tagErrorType))
+		})
+		convey.Convey("winner", func() {
+			as := &attempts{started: []*rpcAttempt{{number: 1, kind: attemptFirst}, {number: 2, kind: attemptRetry}}}
+			assert.Nil(t, as.winner())
+			as.started[1].done = true
+			assert.Equal(t, 2, as.winner().number)
+		})
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// Sampler decides whether the rpc in ctx is sampled.
+type Sampler interface {
+	Sample(ctx context.Context) bool
+}
+
+// SamplerFunc is an adapter to use a function as Sampler.
+type SamplerFunc func(ctx context.Context) bool
+
+// Sample implements the Sampler interface.
+func (f SamplerFunc) Sample(ctx context.Context) bool {
+	return f(ctx)
+}
+
+// AlwaysSample samples every rpc.
+func AlwaysSample() Sampler {
+	return SamplerFunc(func(ctx context.Context) bool { return true })
+}
+
+// NeverSample samples no rpc.
+func NeverSample() Sampler {
+	return SamplerFunc(func(ctx context.Context) bool { return false })
+}
+
+// ProbabilisticSampler samples rpcs with the probability rate in [0, 1].
+func ProbabilisticSampler(rate float64) Sampler {
+	return SamplerFunc(func(ctx context.Context) bool { return rand.Float64() < rate })
+}
+
+// RateLimitingSampler samples at most spansPerSecond rpcs per second, a rate below 1 samples one rpc every 1/spansPerSecond seconds.
+func RateLimitingSampler(spansPerSecond float64) Sampler {
+	// the bucket holds at least one credit, so that a fractional rate can sample at all
+	maxCredit := math.Max(spansPerSecond, 1)
+	return &rateLimitingSampler{rate: spansPerSecond, maxCredit: maxCredit, credit: maxCredit, last: time.Now()}
+}
+
+// rateLimitingSampler is a token bucket holding up to one second of credit.
+type rateLimitingSampler struct {
+	sync.Mutex
+	rate      float64
+	maxCredit float64
+	credit    float64
+	last      time.Time
+}
+
+func (s *rateLimitingSampler) Sample(ctx context.Context) bool {
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	s.credit += now.Sub(s.last).Seconds() * s.rate
+	if s.credit > s.maxCredit {
+		s.credit = s.maxCredit
+	}
+	s.last = now
+	if s.credit < 1 {
+		return false
+	}
+	s.credit--
+	return true
+}
+
+// SamplingRule applies Sampler to the rpcs matching Service, Method and Caller, an empty field matches all.
+// Service and Method are the ones invoked, Caller is the service name of the client.
+type SamplingRule struct {
+	Service string
+	Method  string
+	Caller  string
+	Sampler Sampler
+}
+
+func (r *SamplingRule) match(ri rpcinfo.RPCInfo) bool {
+	ink := ri.Invocation()
+	if r.Service != "" && (ink == nil || ink.ServiceName() != r.Service) {
+		return false
+	}
+	if r.Method != "" && (ink == nil || ink.MethodName() != r.Method) {
+		return false
+	}
+	if r.Caller != "" && (ri.From() == nil || ri.From().ServiceName() != r.Caller) {
+		return false
+	}
+	return true
+}
+
+// SamplingPolicy samples rpcs with the Sampler of the first matching rule, or Default if no rule matches.
+// A sampled rpc starts its span with sampling.priority 1 and an unsampled one with 0,
+// the decision is left to the tracer if no rule matches and Default is nil.
+type SamplingPolicy struct {
+	Rules   []SamplingRule
+	Default Sampler
+	// SkipUnsampled skips span creation for unsampled rpcs instead of setting sampling.priority 0.
+	SkipUnsampled bool
+}
+
+// WithSamplingPolicy sets the policy sampling rpcs per service, method and caller.
+func WithSamplingPolicy(policy SamplingPolicy) Option {
+	return func(o *options) {
+		o.samplingPolicy = &policy
+	}
+}
+
+// sampler returns the Sampler applying to the rpc in ctx, nil if the decision is left to the tracer.
+func (p *SamplingPolicy) sampler(ctx context.Context) Sampler {
+	if ri := rpcinfo.GetRPCInfo(ctx); ri != nil {
+		for i := range p.Rules {
+			if p.Rules[i].match(ri) {
+				return p.Rules[i].Sampler
+			}
+		}
+	}
+	return p.Default
+}
+
+// samplingOptions reports whether a span should be started for the rpc in ctx, and the sampling options to start it with.
+func (p *SamplingPolicy) samplingOptions(ctx context.Context) ([]opentracing.StartSpanOption, bool) {
+	sampler := p.sampler(ctx)
+	if sampler == nil {
+		return nil, true
+	}
+	if sampler.Sample(ctx) {
+		return []opentracing.StartSpanOption{opentracing.Tag{Key: string(ext.SamplingPriority), Value: uint16(1)}}, true
+	}
+	if p.SkipUnsampled {
+		return nil, false
+	}
+	return []opentracing.StartSpanOption{opentracing.Tag{Key: string(ext.SamplingPriority), Value: uint16(0)}}, true
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Samplers(t *testing.T) {
+	convey.Convey("Test_Samplers", t, func() {
+		ctx := context.Background()
+		assert.True(t, AlwaysSample().Sample(ctx))
+		assert.False(t, NeverSample().Sample(ctx))
+		assert.True(t, ProbabilisticSampler(1).Sample(ctx))
+		assert.False(t, ProbabilisticSampler(0).Sample(ctx))
+
+		limiter := RateLimitingSampler(2)
+		assert.True(t, limiter.Sample(ctx))
+		assert.True(t, limiter.Sample(ctx))
+		assert.False(t, limiter.Sample(ctx))
+
+		// one rpc every 2 seconds
+		limiter = RateLimitingSampler(0.5)
+		assert.True(t, limiter.Sample(ctx))
+		assert.False(t, limiter.Sample(ctx))
+		limiter.(*rateLimitingSampler).last = time.Now().Add(-2 * time.Second)
+		assert.True(t, limiter.Sample(ctx))
+		assert.False(t, limiter.Sample(ctx))
+	})
+}
+
+func Test_SamplingPolicy(t *testing.T) {
+	convey.Convey("Test_SamplingPolicy", t, func() {
+		policy := SamplingPolicy{
+			Rules: []SamplingRule{
+				{Method: "Ping", Sampler: NeverSample()},
+				{Service: "echo", Caller: "client", Sampler: AlwaysSample()},
+			},
+		}
+		convey.Convey("first matching rule", func() {
+			tracer := mocktracer.New()
+			ct := newTestClientTracer(WithTracer(tracer), WithSamplingPolicy(policy))
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = ct.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			ct.Finish(ctx)
+			assert.Equal(t, uint16(1), tracer.FinishedSpans()[0].Tag("sampling.priority"))
+		})
+		convey.Convey("no matching rule", func() {
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			opts, ok := (&SamplingPolicy{Rules: policy.Rules[:1]}).samplingOptions(ctx)
+			assert.True(t, ok)
+			assert.Len(t, opts, 0)
+		})
+		convey.Convey("unsampled", func() {
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			opts, ok := (&SamplingPolicy{Default: NeverSample()}).samplingOptions(ctx)
+			assert.True(t, ok)
+			assert.Len(t, opts, 1)
+			_, ok = (&SamplingPolicy{Default: NeverSample(), SkipUnsampled: true}).samplingOptions(ctx)
+			assert.False(t, ok)
+		})
+	})
+}
@@ -22,6 +22,15 @@ import (
 	"github.com/cloudwego/kitex/pkg/transmeta"
 	"github.com/cloudwego/kitex/server"
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	tagHandlerSkipped = "kitex.handler.skipped"
+
+	handlerSkippedDecodeError = "decode_error"
+	handlerSkippedRejected    = "rejected"
+	handlerSkippedUnknown     = "unknown"
 )
 
 var _ stats.Tracer = &serverTracer{}
@@ -33,6 +42,7 @@ type serverTracer struct {
 type traceContainer struct {
 	serverTracer *serverTracer
 	span         opentracing.Span
+	filtered     bool
 }
 
 func (o *serverTracer) Start(ctx context.Context) context.Context {
@@ -42,50 +52,130 @@ func (o *serverTracer) Start(ctx context.Context) context.Context {
 
 func (o *serverTracer) Finish(ctx context.Context) {
 	tc, ok := ctx.Value(traceContainerKey).(*traceContainer)
-	if !ok || tc.span == nil {
-		panic("get tracer container failed")
+	if !ok || tc.filtered {
+		return
 	}
+	ri := rpcinfo.GetRPCInfo(ctx)
+	st := ri.Stats()
+
 	rpcSpan := tc.span
+	if rpcSpan == nil {
+		// SpanContextExtractMW didn't run, the rpc is rejected ahead of it or the suite is wired partially
+		opts, ok := o.startOptions(ctx)
+		if !ok {
+			return
+		}
+		// oneway is told from the nil response the middleware gets, the method info declaring it is not
+		// reachable from the rpcinfo in kitex, so a oneway rpc rejected ahead of the middleware is traced as a
+		// two-way one: ChildOf the client span and without the kitex.oneway tag
+		rpcSpan, _ = o.startSpan(ctx, false, opts...)
+		if st.GetEvent(stats.ServerHandleStart) == nil {
+			rpcSpan.SetTag(tagHandlerSkipped, handlerSkippedReason(st))
+		}
+	}
 
+	recordRPCError(rpcSpan, st)
+	recordContextDone(ctx, rpcSpan, st.Error())
+
+	finishOptions := opentracing.FinishOptions{FinishTime: rpcFinishTime(st)}
+	// record common rpc events
+	o.recordCommonEvents(rpcSpan, st, &finishOptions)
+	// record handler event
+	o.recordEvent(rpcSpan, &finishOptions, st, EventGroupHandler, "handler", stats.ServerHandleStart, stats.ServerHandleFinish)
+
+	o.decorateFinish(ctx, rpcSpan, ri)
+
+	rpcSpan.FinishWithOptions(finishOptions)
+}
+
+// startSpan starts the server span as a child of the span context extracted from metainfo,
+// the span of a oneway call follows from it as the client doesn't wait for the server.
+func (o *serverTracer) startSpan(ctx context.Context, oneway bool, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
 	ri := rpcinfo.GetRPCInfo(ctx)
-	st := ri.Stats()
+	opts = append(opts, ext.SpanKindRPCServer)
+	if event := ri.Stats().GetEvent(stats.RPCStart); event != nil {
+		opts = append(opts, opentracing.StartTime(event.Time()))
+	}
+
+	// tracing must not fail the rpc, a corrupt span context starts a new trace
+	parentContext, extractErr := o.propagator.Extract(ctx, o.tracer)
+	if extractErr == opentracing.ErrSpanContextNotFound {
+		extractErr = nil
+	}
+	if extractErr == nil && parentContext != nil {
+		if oneway {
+			opts = append(opts, opentracing.FollowsFrom(parentContext))
+		} else {
+			opts = append(opts, opentracing.ChildOf(parentContext))
+		}
+	}
 
-	// new common rpc span
-	o.newCommonSpan(rpcSpan, st)
-	// new handler span
-	o.newEventSpan("handler", st, stats.ServerHandleStart, stats.ServerHandleFinish, rpcSpan.Context())
+	rpcSpan, ctx := opentracing.StartSpanFromContextWithTracer(ctx, o.tracer, o.operationName(ctx), opts...)
+	setRPCTags(rpcSpan, ri)
+	setPeerTags(rpcSpan, ri.From())
+	setTimeoutTags(ctx, rpcSpan, ri.Config())
+	if oneway {
+		rpcSpan.SetTag(tagOneway, true)
+	}
+	if extractErr != nil {
+		rpcSpan.SetTag(tagExtractError, extractErr.Error())
+		if o.extractErrorHandler != nil {
+			o.extractErrorHandler(ctx, ri.From(), extractErr)
+		}
+	}
+	if bridge := o.baggageBridge; bridge != nil {
+		bridge.metainfoToBaggage(ctx, rpcSpan)
+	}
+	o.decorateStart(ctx, rpcSpan, ri)
+	return rpcSpan, ctx
+}
 
-	rpcSpan.FinishWithOptions(opentracing.FinishOptions{FinishTime: st.GetEvent(stats.RPCFinish).Time()})
+// handlerSkippedReason tells why the handler never ran from the events recorded in st.
+func handlerSkippedReason(st rpcinfo.RPCStats) string {
+	if event := st.GetEvent(stats.ReadFinish); event != nil {
+		if event.Status() == stats.StatusError {
+			return handlerSkippedDecodeError
+		}
+		// the request is decoded, a middleware ahead of the handler returned
+		return handlerSkippedRejected
+	}
+	if st.Error() != nil {
+		return handlerSkippedRejected
+	}
+	return handlerSkippedUnknown
 }
 
-// serverOption return server option with specified tracer and operation name formater.
-func serverOption(tracer opentracing.Tracer, formOperationName func(c context.Context) string) server.Option {
+// serverOption return server option with the specified options.
+func serverOption(opts *options) server.Option {
 	st := &serverTracer{}
-	st.tracer = tracer
-	st.formOperationName = formOperationName
+	st.options = opts
 	return server.WithTracer(st)
 }
 
+// NewDefaultServerSuite uses opentracing global tracer and `{Service Name}::{Method Name}` as operation name.
 func NewDefaultServerSuite() server.Suite {
-	return &serverSuite{opentracing.GlobalTracer(), func(ctx context.Context) string {
-		endpoint := rpcinfo.GetRPCInfo(ctx).From()
-		return endpoint.ServiceName() + "::" + endpoint.Method()
-	}}
+	return NewServerSuiteWithOptions()
 }
 
+// NewServerSuite returns a server suite with specified tracer and operation name formatter.
 func NewServerSuite(tracer opentracing.Tracer, formOperationName func(c context.Context) string) server.Suite {
-	return &serverSuite{tracer, formOperationName}
+	return NewServerSuiteWithOptions(WithTracer(tracer), WithOperationNameFunc(formOperationName))
+}
+
+// NewServerSuiteWithOptions returns a server suite configured by opts.
+func NewServerSuiteWithOptions(opts ...Option) server.Suite {
+	return &serverSuite{newOptions(opts)}
 }
 
 type serverSuite struct {
-	tracer            opentracing.Tracer
-	formOperationName func(c context.Context) string
+	opts *options
 }
 
 func (c *serverSuite) Options() []server.Option {
 	var options []server.Option
-	options = append(options, serverOption(c.tracer, c.formOperationName))
+	options = append(options, serverOption(c.opts))
 	options = append(options, server.WithMiddleware(SpanContextExtractMW))
 	options = append(options, server.WithMetaHandler(transmeta.ServerTTHeaderHandler))
+	options = append(options, server.WithMetaHandler(transmeta.ServerHTTP2Handler))
 	return options
 }
@@ -0,0 +1,91 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/server"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_serverTracer(t *testing.T) {
+	convey.Convey("Test_serverTracer", t, func() {
+		convey.Convey("extract middleware not run", func() {
+			tracer := mocktracer.New()
+			st := newTestServerTracer(WithTracer(tracer))
+			ctx, ri := newTestRPCInfo(stats.LevelDetailed)
+			ctx = st.Start(ctx)
+			ri.Stats().Record(ctx, stats.ReadStart, stats.StatusInfo, "")
+			ri.Stats().Record(ctx, stats.ReadFinish, stats.StatusError, "decode failed")
+			rpcinfo.AsMutableRPCStats(ri.Stats()).SetError(errors.New("decode failed"))
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			assert.NotPanics(t, func() { st.Finish(ctx) })
+
+			// the read span finishes before the rpc span
+			spans := tracer.FinishedSpans()
+			assert.Len(t, spans, 2)
+			assert.Equal(t, "read", spans[0].OperationName)
+			assert.Equal(t, "client::Call", spans[1].OperationName)
+			assert.Equal(t, handlerSkippedDecodeError, spans[1].Tag(tagHandlerSkipped))
+			assert.Equal(t, true, spans[1].Tag("error"))
+		})
+		convey.Convey("rejected by middleware", func() {
+			tracer := mocktracer.New()
+			st := newTestServerTracer(WithTracer(tracer))
+			ctx, ri := newTestRPCInfo(stats.LevelBase)
+			ctx = st.Start(ctx)
+			rpcinfo.AsMutableRPCStats(ri.Stats()).SetError(errors.New("forbidden"))
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			st.Finish(ctx)
+			assert.Equal(t, handlerSkippedRejected, tracer.FinishedSpans()[0].Tag(tagHandlerSkipped))
+		})
+		convey.Convey("start decorator", func() {
+			tracer := mocktracer.New()
+			st := newTestServerTracer(WithTracer(tracer), WithOnStart(func(ctx context.Context, span opentracing.Span, ri rpcinfo.RPCInfo) {
+				span.SetTag("caller", ri.From().ServiceName())
+			}))
+			ctx, ri := newTestRPCInfo(stats.LevelBase)
+			ctx = st.Start(ctx)
+			ri.Stats().Record(ctx, stats.RPCFinish, stats.StatusInfo, "")
+			st.Finish(ctx)
+			assert.Equal(t, "client", tracer.FinishedSpans()[0].Tag("caller"))
+		})
+		convey.Convey("not started", func() {
+			st := newTestServerTracer(WithTracer(mocktracer.New()))
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			assert.NotPanics(t, func() { st.Finish(context.WithValue(ctx, traceContainerKey, nil)) })
+		})
+	})
+}
+
+func Test_serverSuite(t *testing.T) {
+	convey.Convey("Test_serverSuite", t, func() {
+		o := &server.Options{}
+		for _, opt := range NewDefaultServerSuite().Options() {
+			opt.F(o, &o.DebugInfo)
+		}
+		assert.Contains(t, o.MetaHandlers, transmeta.ServerTTHeaderHandler)
+		assert.Contains(t, o.MetaHandlers, transmeta.ServerHTTP2Handler)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// ServerSpanFromContext returns the span of the rpc served by the handler ctx is given to,
+// nil if the rpc isn't traced by the server suite.
+func ServerSpanFromContext(ctx context.Context) opentracing.Span {
+	tc, ok := ctx.Value(traceContainerKey).(*traceContainer)
+	if !ok || tc == nil {
+		return nil
+	}
+	return tc.span
+}
+
+// TracerFromContext returns the tracer configured on the server suite serving the rpc in ctx,
+// opentracing global tracer if ctx isn't served by the suite.
+func TracerFromContext(ctx context.Context) opentracing.Tracer {
+	if tc, ok := ctx.Value(traceContainerKey).(*traceContainer); ok && tc != nil {
+		return tc.serverTracer.tracer
+	}
+	return opentracing.GlobalTracer()
+}
+
+// StartChildSpan starts a span with the tracer of the server suite, as a child of the span in ctx or of the server span.
+// When the rpc isn't traced, the span is a child of the span context received by the rpc if any, so the trace stays connected.
+// The returned context holds the new span.
+func StartChildSpan(ctx context.Context, operationName string, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		parent = ServerSpanFromContext(ctx)
+	}
+	if parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent.Context()))
+	} else if pt := passThroughFromContext(ctx); pt != nil {
+		opts = append(opts, opentracing.ChildOf(pt.spanContext))
+	}
+	span := TracerFromContext(ctx).StartSpan(operationName, opts...)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
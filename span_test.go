@@ -0,0 +1,84 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/cloudwego/kitex/pkg/streaming"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StartChildSpan(t *testing.T) {
+	convey.Convey("Test_StartChildSpan", t, func() {
+		convey.Convey("traced rpc", func() {
+			tracer := mocktracer.New()
+			st := newTestServerTracer(WithTracer(tracer))
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			ctx = st.Start(ctx)
+			_ = SpanContextExtractMW(func(ctx context.Context, req, resp interface{}) error {
+				assert.Equal(t, tracer, TracerFromContext(ctx))
+				serverSpan := ServerSpanFromContext(ctx).(*mocktracer.MockSpan)
+
+				child, childCtx := StartChildSpan(ctx, "query")
+				assert.Equal(t, serverSpan.SpanContext.SpanID, child.(*mocktracer.MockSpan).ParentID)
+				grandchild, _ := StartChildSpan(childCtx, "scan")
+				assert.Equal(t, child.(*mocktracer.MockSpan).SpanContext.SpanID, grandchild.(*mocktracer.MockSpan).ParentID)
+				assert.Equal(t, serverSpan, ServerSpanFromContext(childCtx))
+				return nil
+			})(ctx, nil, nil)
+		})
+		convey.Convey("streaming rpc", func() {
+			tracer := mocktracer.New()
+			st := newTestServerTracer(WithTracer(tracer))
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			ctx = st.Start(ctx)
+			args := &streaming.Args{Stream: &mockStream{ctx: context.Background()}}
+			_ = SpanContextExtractMW(func(ctx context.Context, req, resp interface{}) error {
+				stream := req.(*streaming.Args).Stream
+				assert.Equal(t, tracer, TracerFromContext(stream.Context()))
+				child, _ := StartChildSpan(stream.Context(), "handle")
+				assert.Equal(t, ServerSpanFromContext(ctx).(*mocktracer.MockSpan).SpanContext.SpanID, child.(*mocktracer.MockSpan).ParentID)
+				return nil
+			})(ctx, args, nil)
+		})
+		convey.Convey("filtered rpc", func() {
+			tracer := mocktracer.New()
+			st := newTestServerTracer(WithTracer(tracer), WithPropagator(TextMapPropagator()), WithFilter(func(ctx context.Context) bool {
+				return false
+			}))
+			parent := tracer.StartSpan("parent")
+			ctx, _ := newTestRPCInfo(stats.LevelDetailed)
+			ctx, _ = TextMapPropagator().Inject(ctx, tracer, parent.Context())
+			ctx = st.Start(metainfo.TransferForward(ctx))
+			_ = SpanContextExtractMW(func(ctx context.Context, req, resp interface{}) error {
+				assert.Nil(t, ServerSpanFromContext(ctx))
+				child, _ := StartChildSpan(ctx, "query")
+				assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, child.(*mocktracer.MockSpan).ParentID)
+				return nil
+			})(ctx, nil, nil)
+		})
+		convey.Convey("outside the server suite", func() {
+			assert.Nil(t, ServerSpanFromContext(context.Background()))
+			assert.Equal(t, opentracing.GlobalTracer(), TracerFromContext(context.Background()))
+		})
+	})
+}
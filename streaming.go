@@ -0,0 +1,95 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"github.com/cloudwego/kitex/pkg/streaming"
+	"github.com/opentracing/opentracing-go"
+	tracerLog "github.com/opentracing/opentracing-go/log"
+)
+
+const (
+	tagStreaming = "kitex.streaming"
+
+	logMessageType = "message.type"
+	logMessageID   = "message.id"
+	logMessageSize = "message.uncompressed_size"
+
+	messageSent     = "SENT"
+	messageReceived = "RECEIVED"
+)
+
+// sizer is implemented by the generated protobuf messages.
+type sizer interface {
+	Size() int
+}
+
+// tracedStream logs each message sent and received on the stream span,
+// and exposes the span in its context so that handler code can start child spans inside Recv/Send loops.
+type tracedStream struct {
+	streaming.Stream
+	ctx     context.Context
+	span    opentracing.Span
+	sendSeq int64
+	recvSeq int64
+}
+
+func newTracedStream(stream streaming.Stream, span opentracing.Span) *tracedStream {
+	span.SetTag(tagStreaming, true)
+	return &tracedStream{
+		Stream: stream,
+		ctx:    opentracing.ContextWithSpan(stream.Context(), span),
+		span:   span,
+	}
+}
+
+// Context returns the stream context holding the stream span.
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tracedStream) SendMsg(m interface{}) error {
+	err := s.Stream.SendMsg(m)
+	s.logMessage(messageSent, atomic.AddInt64(&s.sendSeq, 1), m, err)
+	return err
+}
+
+func (s *tracedStream) RecvMsg(m interface{}) error {
+	err := s.Stream.RecvMsg(m)
+	if err == io.EOF {
+		// end of the stream, not a message
+		return err
+	}
+	s.logMessage(messageReceived, atomic.AddInt64(&s.recvSeq, 1), m, err)
+	return err
+}
+
+func (s *tracedStream) logMessage(messageType string, seq int64, m interface{}, err error) {
+	fields := []tracerLog.Field{
+		tracerLog.String(logEvent, "message"),
+		tracerLog.String(logMessageType, messageType),
+		tracerLog.Int64(logMessageID, seq),
+	}
+	if err != nil {
+		fields = append(fields, tracerLog.Error(err))
+	} else if sm, ok := m.(sizer); ok {
+		fields = append(fields, tracerLog.Int(logMessageSize, sm.Size()))
+	}
+	s.span.LogFields(fields...)
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/streaming"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStream struct {
+	ctx  context.Context
+	recv []error
+}
+
+func (s *mockStream) Context() context.Context    { return s.ctx }
+func (s *mockStream) SendMsg(m interface{}) error { return nil }
+func (s *mockStream) Close() error                { return nil }
+
+func (s *mockStream) RecvMsg(m interface{}) error {
+	err := s.recv[0]
+	s.recv = s.recv[1:]
+	return err
+}
+
+type mockMessage struct{}
+
+func (m *mockMessage) Size() int { return 42 }
+
+func logField(record mocktracer.MockLogRecord, key string) string {
+	for _, f := range record.Fields {
+		if f.Key == key {
+			return f.ValueString
+		}
+	}
+	return ""
+}
+
+func Test_tracedStream(t *testing.T) {
+	convey.Convey("Test_tracedStream", t, func() {
+		span := mocktracer.New().StartSpan("stream").(*mocktracer.MockSpan)
+		var stream streaming.Stream = &mockStream{ctx: context.Background(), recv: []error{nil, errors.New("reset"), io.EOF}}
+		stream = newTracedStream(stream, span)
+		assert.Equal(t, span, opentracing.SpanFromContext(stream.Context()))
+		assert.Equal(t, true, span.Tag(tagStreaming))
+
+		assert.Nil(t, stream.SendMsg(&mockMessage{}))
+		assert.Nil(t, stream.SendMsg(&mockMessage{}))
+		assert.Nil(t, stream.RecvMsg(&mockMessage{}))
+		assert.NotNil(t, stream.RecvMsg(&mockMessage{}))
+		assert.Equal(t, io.EOF, stream.RecvMsg(&mockMessage{}))
+
+		logs := span.Logs()
+		assert.Len(t, logs, 4)
+		assert.Equal(t, messageSent, logField(logs[1], logMessageType))
+		assert.Equal(t, "2", logField(logs[1], logMessageID))
+		assert.Equal(t, "42", logField(logs[1], logMessageSize))
+		assert.Equal(t, messageReceived, logField(logs[3], logMessageType))
+		assert.Equal(t, "2", logField(logs[3], logMessageID))
+		assert.Equal(t, "reset", logField(logs[3], "error.object"))
+	})
+}
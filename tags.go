@@ -0,0 +1,111 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	componentName = "kitex"
+
+	tagRPCService = "rpc.service"
+	tagRPCMethod  = "rpc.method"
+	tagOneway     = "kitex.oneway"
+
+	tagPeerAddress    = "peer.address"
+	tagPeerInstance   = "kitex.peer."
+	instanceTagWeight = "weight"
+)
+
+// defaultInstanceTags are the discovery instance tags copied to client spans by default.
+var defaultInstanceTags = []string{"cluster", "idc", "env", instanceTagWeight}
+
+// instanceGetter is implemented by the remote endpoint of the client, which holds the instance picked by the load balancer.
+type instanceGetter interface {
+	GetInstance() discovery.Instance
+}
+
+// setRPCTags sets the standard tags describing the rpc invocation on span.
+func setRPCTags(span opentracing.Span, ri rpcinfo.RPCInfo) {
+	ext.Component.Set(span, componentName)
+	if ink := ri.Invocation(); ink != nil {
+		span.SetTag(tagRPCService, ink.ServiceName())
+		span.SetTag(tagRPCMethod, ink.MethodName())
+	}
+}
+
+// setPeerTags sets the standard peer tags from the endpoint on the other side of the rpc.
+func setPeerTags(span opentracing.Span, peer rpcinfo.EndpointInfo) {
+	if peer == nil {
+		return
+	}
+	if svc := peer.ServiceName(); svc != "" {
+		ext.PeerService.Set(span, svc)
+	}
+	setPeerAddrTags(span, peer.Address())
+}
+
+// setPeerAddrTags sets peer.ipv4/peer.ipv6 or peer.hostname and peer.port from addr.
+func setPeerAddrTags(span opentracing.Span, addr net.Addr) {
+	if addr == nil {
+		return
+	}
+	span.SetTag(tagPeerAddress, addr.String())
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		// unix socket or address without port
+		ext.PeerHostname.Set(span, addr.String())
+		return
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			span.SetTag(string(ext.PeerHostIPv4), ip4.String())
+		} else {
+			ext.PeerHostIPv6.Set(span, ip.String())
+		}
+	} else {
+		ext.PeerHostname.Set(span, host)
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err == nil {
+		ext.PeerPort.Set(span, uint16(p))
+	}
+}
+
+// setInstanceTags copies the tags keys of the discovery instance of peer to span as `kitex.peer.{key}`,
+// the "weight" key stands for the weight of the instance.
+func setInstanceTags(span opentracing.Span, peer rpcinfo.EndpointInfo, keys []string) {
+	if peer == nil {
+		return
+	}
+	for _, k := range keys {
+		if k == instanceTagWeight {
+			if ig, ok := peer.(instanceGetter); ok && ig.GetInstance() != nil {
+				span.SetTag(tagPeerInstance+k, ig.GetInstance().Weight())
+			}
+			continue
+		}
+		// Tag falls back to the tags of the instance
+		if v, ok := peer.Tag(k); ok {
+			span.SetTag(tagPeerInstance+k, v)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentracing
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/rpcinfo/remoteinfo"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_setPeerTags(t *testing.T) {
+	convey.Convey("Test_setPeerTags", t, func() {
+		convey.Convey("ipv4 address", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:8888")
+			setPeerTags(span, rpcinfo.NewEndpointInfo("echo", "Echo", addr, nil))
+			assert.Equal(t, "echo", span.Tag("peer.service"))
+			assert.Equal(t, "127.0.0.1", span.Tag("peer.ipv4"))
+			assert.Equal(t, uint16(8888), span.Tag("peer.port"))
+			assert.Equal(t, "127.0.0.1:8888", span.Tag("peer.address"))
+		})
+		convey.Convey("ipv6 address", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			addr, _ := net.ResolveTCPAddr("tcp", "[::1]:8888")
+			setPeerTags(span, rpcinfo.NewEndpointInfo("echo", "Echo", addr, nil))
+			assert.Equal(t, "::1", span.Tag("peer.ipv6"))
+		})
+		convey.Convey("unix address", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			addr, _ := net.ResolveUnixAddr("unix", "/tmp/echo.sock")
+			setPeerTags(span, rpcinfo.NewEndpointInfo("", "Echo", addr, nil))
+			assert.Nil(t, span.Tag("peer.service"))
+			assert.Equal(t, "/tmp/echo.sock", span.Tag("peer.hostname"))
+		})
+		convey.Convey("nil endpoint", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			setPeerTags(span, nil)
+			assert.Len(t, span.Tags(), 0)
+		})
+	})
+}
+
+func Test_setInstanceTags(t *testing.T) {
+	convey.Convey("Test_setInstanceTags", t, func() {
+		to := remoteinfo.NewRemoteInfo(&rpcinfo.EndpointBasicInfo{ServiceName: "echo"}, "Echo")
+		to.SetInstance(discovery.NewInstance("tcp", "10.0.0.1:8888", 10, map[string]string{"cluster": "c1", "idc": "hl"}))
+		convey.Convey("default tags", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			setInstanceTags(span, to, defaultInstanceTags)
+			assert.Equal(t, "c1", span.Tag("kitex.peer.cluster"))
+			assert.Equal(t, "hl", span.Tag("kitex.peer.idc"))
+			assert.Nil(t, span.Tag("kitex.peer.env"))
+			assert.Equal(t, 10, span.Tag("kitex.peer.weight"))
+		})
+		convey.Convey("selected tags", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			setInstanceTags(span, to, []string{"idc"})
+			assert.Len(t, span.Tags(), 1)
+		})
+		convey.Convey("no instance", func() {
+			span := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			setInstanceTags(span, rpcinfo.NewEndpointInfo("echo", "Echo", nil, nil), defaultInstanceTags)
+			assert.Len(t, span.Tags(), 0)
+		})
+	})
+}